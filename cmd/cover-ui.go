@@ -5,47 +5,146 @@ Licensed under the MIT license, see LICENSE in the project root for details.
 package coverui
 
 import (
+	"context"
+	"errors"
 	"flag"
 	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
 	"os"
+	"os/signal"
+	"path"
 	"path/filepath"
+	"runtime"
+	"slices"
+	"sort"
 	"strings"
 	"time"
 
 	"golang.org/x/tools/cover"
 
+	"github.com/tschaefer/cover-ui/internal/covdata"
 	"github.com/tschaefer/cover-ui/internal/coverage"
+	"github.com/tschaefer/cover-ui/internal/delta"
+	"github.com/tschaefer/cover-ui/internal/diff"
+	"github.com/tschaefer/cover-ui/internal/exclude"
+	"github.com/tschaefer/cover-ui/internal/generator/badge"
+	"github.com/tschaefer/cover-ui/internal/generator/cobertura"
+	"github.com/tschaefer/cover-ui/internal/generator/compare"
 	"github.com/tschaefer/cover-ui/internal/generator/file"
 	"github.com/tschaefer/cover-ui/internal/generator/index"
+	"github.com/tschaefer/cover-ui/internal/generator/lcov"
+	"github.com/tschaefer/cover-ui/internal/generator/markdown"
+	"github.com/tschaefer/cover-ui/internal/generator/report"
+	"github.com/tschaefer/cover-ui/internal/generator/text"
+	"github.com/tschaefer/cover-ui/internal/gotest"
+	"github.com/tschaefer/cover-ui/internal/history"
+	"github.com/tschaefer/cover-ui/internal/manifest"
 	"github.com/tschaefer/cover-ui/internal/module"
+	"github.com/tschaefer/cover-ui/internal/progress"
+	"github.com/tschaefer/cover-ui/internal/server"
+	"github.com/tschaefer/cover-ui/internal/source"
+	"github.com/tschaefer/cover-ui/internal/threshold"
+	"github.com/tschaefer/cover-ui/internal/untested"
 	"github.com/tschaefer/cover-ui/internal/version"
+	"github.com/tschaefer/cover-ui/internal/watch"
 )
 
 var (
-	profileFile = flag.String("profile", "coverage.out", "coverage profile file")
-	outDir      = flag.String("out", "coverage", "output directory for generated html files")
-	srcRoot     = flag.String("src", ".", "source root directory on disk")
-	cleanOutDir = flag.Bool("clean", false, "clean output directory before generating files")
-	versionInfo = flag.Bool("version", false, "print version and exit")
-	quiet       = flag.Bool("quiet", false, "suppress progress and statistics output")
+	profileFile  = flag.String("profile", "coverage.out", "coverage profile files, comma separated or glob pattern")
+	coverDir     = flag.String("coverdir", "", "binary coverage directories (GOCOVERDIR), comma separated")
+	outDir       = flag.String("out", "coverage", "output directory for generated report files")
+	formats      = flag.String("format", "html", "report formats, comma separated: html, json, cobertura, lcov, badge, markdown, text (alias summary)")
+	srcRoot      = flag.String("src", ".", "source root directory on disk")
+	rewrites     = flag.String("rewrite", "", "profile path prefix rewrites, comma separated from=to rules")
+	withUntested = flag.Bool("include-untested", false, "add files of packages missing from the profile as not covered")
+	includes     = flag.String("include", "", "only report files matching these glob patterns, comma separated")
+	excludes     = flag.String("exclude", strings.Join(exclude.Defaults, ","), "leave out files matching these glob patterns, comma separated")
+	skipGen      = flag.Bool("exclude-generated", true, "leave out files with a generated code header")
+	badgeLabel   = flag.String("badge-label", badge.DefaultOptions().Label, "badge: label text")
+	badgeRed     = flag.Float64("badge-red", badge.DefaultOptions().Red, "badge: coverage percentage at and below which the badge is red")
+	badgeGreen   = flag.Float64("badge-green", badge.DefaultOptions().Green, "badge: coverage percentage at and above which the badge is green")
+	badgePkgs    = flag.Bool("badge-packages", false, "badge: write a badge per package below badges/")
+	mdBase       = flag.String("markdown-base", "", "markdown: base profile or JSON report to show coverage deltas against")
+	mdFiles      = flag.Int("markdown-files", markdown.DefaultOptions().MaxFiles, "markdown: number of lowest covered files to list")
+	mdMaxBytes   = flag.Int("markdown-max-bytes", markdown.DefaultOptions().MaxBytes, "markdown: size limit of the summary, 0 disables the limit")
+	textDepth    = flag.Int("text-depth", 0, "text: directory levels to show, 0 shows all levels")
+	textSort     = flag.String("text-sort", text.SortName, "text: row order within a directory: "+strings.Join(text.SortOrders, ", "))
+	historyFile  = flag.String("history", "", "JSON lines file to append the totals of each run to, shown as trend")
+	singleFile   = flag.Bool("single-file", false, "html: inline styles, scripts and file pages into a single index.html")
+	cleanOutDir  = flag.Bool("clean", false, "clean output directory before generating files")
+	versionInfo  = flag.Bool("version", false, "print version and exit")
+	diffBase     = flag.String("diff-base", "", "git revision to compute patch coverage against")
+	minTotal     = flag.Float64("min-total", 0, "minimum total coverage percentage")
+	minPackage   = flag.Float64("min-package", 0, "minimum coverage percentage per package")
+	minFile      = flag.Float64("min-file", 0, "minimum coverage percentage per file")
+	minPatch     = flag.Float64("min-patch", 0, "minimum patch coverage percentage, requires -diff-base")
+	quiet        = flag.Bool("quiet", false, "suppress progress and statistics output")
+	listenAddr   = flag.String("listen", "localhost:8000", "serve: address to listen on")
+	liveReload   = flag.Bool("live", false, "serve: regenerate on profile or source changes and reload open pages")
 )
 
+// exitThreshold is the exit status for reports below a coverage threshold
+const exitThreshold = 2
+
+// watchInterval is the polling interval of the serve mode
+const watchInterval = time.Second
+
 func Run() {
-	flag.Parse()
+	command := parseArgs()
 
 	printVersion()
 
-	err := removeOldFiles()
-	checkErr(err)
+	thresholds := threshold.Thresholds{
+		Total:   *minTotal,
+		Package: *minPackage,
+		File:    *minFile,
+		Patch:   *minPatch,
+	}
+	checkErr(thresholds.Validate())
+	checkErr(validateFormats())
+	if slices.Contains(selectedFormats(), "badge") {
+		checkErr(badgeOptions().Validate())
+	}
+	if slices.Contains(selectedFormats(), "text") {
+		checkErr(textOptions().Validate())
+	}
+	if *minPatch > 0 && *diffBase == "" {
+		checkErr(fmt.Errorf("-min-patch requires -diff-base"))
+	}
+
+	switch command {
+	case "":
+		files, patch, err := generate(nil, true)
+		checkErr(err)
+
+		checkThresholds(files, patch, thresholds)
+	case "serve":
+		_, _, err := generate(nil, false)
+		checkErr(err)
+
+		checkErr(serve())
+	case "watch":
+		checkErr(watchTests())
+	case "compare":
+		checkErr(compareReports())
+	default:
+		checkErr(fmt.Errorf("unknown command %s", command))
+	}
+}
 
-	module, err := module.Read(*srcRoot)
-	checkErr(err)
+// parseArgs parses the flags following an optional command
+func parseArgs() string {
+	args := os.Args[1:]
+	command := ""
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		command, args = args[0], args[1:]
+	}
 
-	files, err := analyzeProfile(module)
-	checkErr(err)
+	_ = flag.CommandLine.Parse(args)
 
-	err = generateHtmlFiles(files, module)
-	checkErr(err)
+	return command
 }
 
 func printVersion() {
@@ -57,6 +156,328 @@ func printVersion() {
 	os.Exit(0)
 }
 
+// generate runs the whole pipeline, file pages are only written for the
+// files accepted by pages or for all files if pages is nil. With record, the
+// totals are appended to the history file.
+func generate(pages func(*coverage.FileMetrics) bool, record bool) ([]*coverage.FileMetrics, *coverage.PatchMetrics, error) {
+	if pages == nil {
+		if err := removeOldFiles(); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	modules, err := module.Discover(*srcRoot)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	src, err := sourceResolver()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	files, excluded, err := analyzeProfile(modules, src)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	trend, err := readHistory(files)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	patch, err := analyzeDiff(files)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := generateReports(files, excluded, modules, src, patch, trend, pages); err != nil {
+		return nil, nil, err
+	}
+
+	// Only runs with a complete report are recorded
+	if record && len(trend) > 0 {
+		if err := history.Append(*historyFile, trend[len(trend)-1]); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	return files, patch, nil
+}
+
+func serve() error {
+	srv := server.New(*outDir)
+
+	if *liveReload {
+		w := watch.New(watchInterval)
+		w.AddTree(*srcRoot, ".go")
+		for _, pattern := range splitList(*profileFile) {
+			w.AddGlob(pattern)
+		}
+		for _, dir := range splitList(*coverDir) {
+			w.AddTree(dir, "")
+		}
+
+		go w.Run(context.Background(), func(changed []string) {
+			if _, _, err := generate(nil, false); err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: failed to regenerate report: %v\n", err)
+				return
+			}
+			srv.Reload()
+		})
+	}
+
+	if !*quiet {
+		fmt.Printf("Serving coverage report at http://%s/\n", *listenAddr)
+	}
+
+	return http.ListenAndServe(*listenAddr, srv)
+}
+
+// compareReports generates the comparison of a base and a head profile or
+// JSON report, given as arguments
+func compareReports() error {
+	args := flag.Args()
+	if len(args) != 2 {
+		return fmt.Errorf("compare requires a base and a head profile or JSON report")
+	}
+
+	if err := removeOldFiles(); err != nil {
+		return err
+	}
+
+	base, err := readReport(args[0])
+	if err != nil {
+		return err
+	}
+	head, err := readReport(args[1])
+	if err != nil {
+		return err
+	}
+
+	src, err := sourceResolver()
+	if err != nil {
+		return err
+	}
+
+	c := delta.Compare(base, head)
+
+	filesDir := filepath.Join(*outDir, "tree")
+	if err := file.Assets(filesDir); err != nil {
+		return err
+	}
+
+	errs, err := generatePages(head, filesDir, src, nil)
+	if err != nil {
+		return err
+	}
+
+	pages := make(map[string]bool, len(head))
+	for i, f := range head {
+		if err := errs[i]; err != nil {
+			// Head files of a JSON report may not exist below the source root
+			if !errors.Is(err, fs.ErrNotExist) {
+				fmt.Fprintf(os.Stderr, "Warning: skipping page of %s: %v\n", f.LocalPath, err)
+			}
+			continue
+		}
+		pages[f.LocalPath] = true
+	}
+
+	if err := compare.Generate(c, *outDir, args[0], args[1], func(localPath string) bool {
+		return pages[localPath]
+	}); err != nil {
+		return err
+	}
+
+	if !*quiet {
+		fmt.Printf("Coverage changed from %.2f%% to %.2f%% (%+.2f).\n", c.Base.CoveragePct, c.Head.CoveragePct, c.Delta())
+	}
+
+	return nil
+}
+
+// readReport returns the file metrics of a JSON report or, for any other
+// file, of a coverage profile analyzed against the source root
+func readReport(path string) ([]*coverage.FileMetrics, error) {
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		r, err := report.Read(path)
+		if err != nil {
+			return nil, err
+		}
+		return r.FileMetrics(), nil
+	}
+
+	profiles, err := cover.ParseProfiles(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse coverage profile %s: %w", path, err)
+	}
+
+	modules, err := module.Discover(*srcRoot)
+	if err != nil {
+		return nil, err
+	}
+
+	src, err := sourceResolver()
+	if err != nil {
+		return nil, err
+	}
+
+	files, _, err := analyzeProfiles(profiles, modules, src)
+	if err != nil {
+		return nil, fmt.Errorf("failed to analyze %s: %w", path, err)
+	}
+
+	return files, nil
+}
+
+func watchTests() error {
+	if *coverDir != "" {
+		return fmt.Errorf("watch does not support -coverdir")
+	}
+
+	paths := splitList(*profileFile)
+	if len(paths) != 1 || strings.ContainsAny(paths[0], "*?[") {
+		return fmt.Errorf("watch requires a single -profile file")
+	}
+	profilePath := paths[0]
+
+	modules, err := module.Discover(*srcRoot)
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	if _, err := os.Stat(profilePath); errors.Is(err, fs.ErrNotExist) {
+		if _, err := runTests(ctx, modules, profilePath, []string{"..."}); err != nil {
+			return err
+		}
+	}
+
+	if _, _, err := generate(nil, false); err != nil {
+		return err
+	}
+
+	w := watch.New(watchInterval)
+	w.AddTree(*srcRoot, ".go")
+
+	if !*quiet {
+		fmt.Printf("Watching %s for changes, press Ctrl+C to stop\n", *srcRoot)
+	}
+
+	w.Run(ctx, func(changed []string) {
+		dirs := gotest.Packages(*srcRoot, changed)
+		if len(dirs) == 0 {
+			return
+		}
+
+		impacted, err := runTests(ctx, modules, profilePath, dirs)
+		if err == nil {
+			_, _, err = generate(func(f *coverage.FileMetrics) bool {
+				return impacted[f.FileName]
+			}, false)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		}
+	})
+
+	return nil
+}
+
+// runTests reruns the tests of the package directories, replaces their
+// results in the profile and returns the profile file names of the tested
+// files. Tests run within the module directory of each package. Packages
+// failing to build keep their previous results, removed packages are
+// dropped from the profile.
+func runTests(ctx context.Context, modules module.Modules, profilePath string, dirs []string) (map[string]bool, error) {
+	var base []*cover.Profile
+	if _, err := os.Stat(profilePath); err == nil {
+		if base, err = cover.ParseProfiles(profilePath); err != nil {
+			return nil, fmt.Errorf("failed to parse coverage profile %s: %w", profilePath, err)
+		}
+	}
+
+	mode := ""
+	if len(base) > 0 {
+		mode = base[0].Mode
+	}
+
+	runs := make(map[module.Module][]string)
+	var replaced []string
+	for _, dir := range dirs {
+		if dir == "..." {
+			for _, mod := range modules {
+				runs[mod] = append(runs[mod], dir)
+			}
+			continue
+		}
+
+		mod, importPath, ok := modules.Package(dir)
+		if !ok {
+			continue
+		}
+		if gotest.HasGoFiles(filepath.Join(*srcRoot, dir)) {
+			runs[mod] = append(runs[mod], mod.Rel(dir))
+			continue
+		}
+		replaced = append(replaced, importPath)
+	}
+
+	var fresh []*cover.Profile
+	for _, mod := range modules {
+		run := runs[mod]
+		if len(run) == 0 {
+			continue
+		}
+
+		if !*quiet {
+			fmt.Printf("Running go test for %s in %s\n", strings.Join(run, ", "), mod.Path)
+		}
+
+		profiles, output, err := gotest.Run(ctx, filepath.Join(*srcRoot, mod.Dir), mode, run)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s", output)
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		}
+		fresh = append(fresh, profiles...)
+	}
+
+	impacted := make(map[string]bool)
+	for _, p := range fresh {
+		impacted[p.FileName] = true
+		replaced = append(replaced, path.Dir(p.FileName))
+	}
+
+	if len(fresh) == 0 && len(base) == 0 {
+		return nil, fmt.Errorf("no coverage profile produced by go test")
+	}
+
+	if err := writeProfile(profilePath, coverage.Replace(base, fresh, replaced)); err != nil {
+		return nil, err
+	}
+
+	return impacted, nil
+}
+
+func writeProfile(path string, profiles []*cover.Profile) error {
+	w, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create coverage profile: %w", err)
+	}
+	defer func() {
+		_ = w.Close()
+	}()
+
+	if err := coverage.WriteProfile(w, profiles); err != nil {
+		return fmt.Errorf("failed to write coverage profile: %w", err)
+	}
+
+	return nil
+}
+
 func removeOldFiles() error {
 	if !*cleanOutDir {
 		return nil
@@ -65,30 +486,368 @@ func removeOldFiles() error {
 	return os.RemoveAll(*outDir)
 }
 
-func analyzeProfile(module string) ([]*coverage.FileMetrics, error) {
-	profiles, err := cover.ParseProfiles(*profileFile)
+func sourceResolver() (*source.Resolver, error) {
+	var rules []source.Rewrite
+	for _, value := range splitList(*rewrites) {
+		rule, err := source.ParseRewrite(value)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, rule)
+	}
+
+	return source.New(*srcRoot, rules...), nil
+}
+
+func analyzeProfile(modules module.Modules, src *source.Resolver) ([]*coverage.FileMetrics, []exclude.File, error) {
+	profiles, err := parseProfiles()
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse coverage profile: %w", err)
+		return nil, nil, err
+	}
+
+	return analyzeProfiles(profiles, modules, src)
+}
+
+// analyzeProfiles returns the metrics of the profiled files and the files
+// left out by the exclusion rules
+func analyzeProfiles(profiles []*cover.Profile, modules module.Modules, src *source.Resolver) ([]*coverage.FileMetrics, []exclude.File, error) {
+	filter, err := exclude.New(splitList(*includes), splitList(*excludes))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if *withUntested {
+		missing, err := untestedProfiles(profiles, modules, src)
+		if err != nil {
+			return nil, nil, err
+		}
+		profiles = append(profiles, missing...)
 	}
 
 	var files []*coverage.FileMetrics
+	var excluded []exclude.File
 	for _, profile := range profiles {
-		metrics, err := coverage.Analyze(profile, module, *srcRoot)
+		if file, ok := excludeProfile(profile, modules, src, filter); ok {
+			excluded = append(excluded, file)
+			continue
+		}
+
+		metrics, err := coverage.Analyze(profile, modules, src)
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "Warning: skipping %s: %v\n", profile.FileName, err)
+			// Missing sources are reported at once below
+			if !errors.Is(err, fs.ErrNotExist) {
+				fmt.Fprintf(os.Stderr, "Warning: skipping %s: %v\n", profile.FileName, err)
+			}
+			continue
+		}
+		if metrics.TotalStmts == 0 && len(profile.Blocks) > 0 {
+			excluded = append(excluded, exclude.File{FileName: profile.FileName, LocalPath: metrics.LocalPath, Reason: exclude.ReasonIgnored})
 			continue
 		}
 		files = append(files, metrics)
 	}
 
+	if missing := src.Missing(); len(missing) > 0 {
+		fmt.Fprintf(os.Stderr, "Warning: skipping %d files not found below source root %s:\n", len(missing), *srcRoot)
+		for _, m := range missing {
+			fmt.Fprintf(os.Stderr, "  - %s (%s)\n", m.Name, m.Path)
+		}
+	}
+
 	if len(files) == 0 {
-		return nil, fmt.Errorf("no valid coverage profiles found in %s", *profileFile)
+		return nil, nil, fmt.Errorf("no valid coverage profiles found")
 	}
 
-	return files, nil
+	sort.Slice(excluded, func(i, j int) bool {
+		return excluded[i].LocalPath < excluded[j].LocalPath
+	})
+
+	return files, excluded, nil
+}
+
+// excludeProfile checks the file of a profile against the filter, errors
+// are left to the analysis
+func excludeProfile(profile *cover.Profile, modules module.Modules, src *source.Resolver, filter *exclude.Filter) (exclude.File, bool) {
+	localPath, err := coverage.LocalPath(profile.FileName, modules, src)
+	if err != nil {
+		return exclude.File{}, false
+	}
+
+	file := exclude.File{FileName: profile.FileName, LocalPath: localPath}
+	if reason, pattern, ok := filter.Match(localPath); ok {
+		file.Reason, file.Pattern = reason, pattern
+		return file, true
+	}
+
+	if !*skipGen {
+		return exclude.File{}, false
+	}
+	source, err := src.ReadFile(localPath)
+	if err != nil || !exclude.IsGenerated(source) {
+		return exclude.File{}, false
+	}
+	file.Reason = exclude.ReasonGenerated
+
+	return file, true
+}
+
+// untestedProfiles returns not covered profiles of all module files that
+// are missing from the parsed profiles
+func untestedProfiles(profiles []*cover.Profile, modules module.Modules, src *source.Resolver) ([]*cover.Profile, error) {
+	mode := "set"
+	tested := make([]string, 0, len(profiles))
+	for _, p := range profiles {
+		mode = p.Mode
+
+		// Profiles not matching a module are reported by the analysis
+		localPath, err := coverage.LocalPath(p.FileName, modules, src)
+		if err != nil {
+			continue
+		}
+		tested = append(tested, src.Path(localPath))
+	}
+
+	missing, err := untested.Profiles(*srcRoot, modules, tested, mode)
+	if err != nil {
+		return nil, fmt.Errorf("failed to collect untested files: %w", err)
+	}
+
+	return missing, nil
+}
+
+func parseProfiles() ([]*cover.Profile, error) {
+	var parsed [][]*cover.Profile
+	for _, dir := range splitList(*coverDir) {
+		profiles, err := covdata.Read(dir)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read coverage directory %s: %w", dir, err)
+		}
+		parsed = append(parsed, profiles)
+	}
+
+	if *coverDir != "" && !isFlagSet("profile") {
+		return coverage.Merge(parsed...)
+	}
+
+	paths, err := profilePaths()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, path := range paths {
+		profiles, err := cover.ParseProfiles(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse coverage profile %s: %w", path, err)
+		}
+		parsed = append(parsed, profiles)
+	}
+
+	return coverage.Merge(parsed...)
+}
+
+func profilePaths() ([]string, error) {
+	var paths []string
+	for _, pattern := range splitList(*profileFile) {
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid profile pattern %s: %w", pattern, err)
+		}
+		if len(matches) == 0 {
+			return nil, fmt.Errorf("no coverage profile found for %s", pattern)
+		}
+		paths = append(paths, matches...)
+	}
+
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("no coverage profile given")
+	}
+
+	return paths, nil
+}
+
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+		if item != "" {
+			items = append(items, item)
+		}
+	}
+
+	return items
+}
+
+func isFlagSet(name string) bool {
+	set := false
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			set = true
+		}
+	})
+
+	return set
+}
+
+// readHistory returns the entries of the history file followed by the
+// entry of the current run
+func readHistory(files []*coverage.FileMetrics) ([]history.Entry, error) {
+	if *historyFile == "" {
+		return nil, nil
+	}
+
+	entries, err := history.Read(*historyFile)
+	if err != nil {
+		return nil, err
+	}
+
+	// Runs outside of a git repository are recorded without commit
+	commit, _ := diff.Head(*srcRoot)
+	current := history.NewEntry(files, commit, time.Now())
+
+	return append(entries, current), nil
+}
+
+// analyzeDiff marks the lines changed against -diff-base in the files and
+// returns the patch coverage, nil without a diff base
+func analyzeDiff(files []*coverage.FileMetrics) (*coverage.PatchMetrics, error) {
+	if *diffBase == "" {
+		return nil, nil
+	}
+
+	changes, err := diff.Changes(*srcRoot, *diffBase)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compute changes against %s: %w", *diffBase, err)
+	}
+
+	return coverage.Patch(files, changes, *diffBase), nil
+}
+
+// listedFiles returns the files listed on the index page, only the files
+// touched by the patch for reports against a diff base
+func listedFiles(files []*coverage.FileMetrics, patch *coverage.PatchMetrics) []*coverage.FileMetrics {
+	if patch == nil {
+		return files
+	}
+
+	return coverage.Touched(files)
+}
+
+// supportedFormats lists the report formats selectable with -format
+var supportedFormats = []string{"html", "json", "cobertura", "lcov", "badge", "markdown", "text", "summary"}
+
+// formatAliases maps alternative format names to the format they select
+var formatAliases = map[string]string{"summary": "text"}
+
+func validateFormats() error {
+	selected := splitList(*formats)
+	if len(selected) == 0 {
+		return fmt.Errorf("no report format given")
+	}
+
+	for _, format := range selected {
+		if !slices.Contains(supportedFormats, format) {
+			return fmt.Errorf("unsupported report format %s, must be one of %s", format, strings.Join(supportedFormats, ", "))
+		}
+	}
+
+	return nil
+}
+
+// selectedFormats returns the formats given by -format with aliases
+// resolved, each format once
+func selectedFormats() []string {
+	var selected []string
+	for _, format := range splitList(*formats) {
+		if alias, ok := formatAliases[format]; ok {
+			format = alias
+		}
+		if !slices.Contains(selected, format) {
+			selected = append(selected, format)
+		}
+	}
+
+	return selected
 }
 
-func generateHtmlFiles(files []*coverage.FileMetrics, module string) error {
+// badgeOptions returns the badge options given by the flags
+func badgeOptions() badge.Options {
+	opts := badge.DefaultOptions()
+	opts.Label = *badgeLabel
+	opts.Red = *badgeRed
+	opts.Green = *badgeGreen
+	opts.Packages = *badgePkgs
+
+	return opts
+}
+
+// textOptions returns the terminal table options given by the flags
+func textOptions() text.Options {
+	opts := text.DefaultOptions()
+	opts.Depth = *textDepth
+	opts.Sort = *textSort
+
+	return opts
+}
+
+// generateMarkdown writes the Markdown summary, with deltas against the
+// -markdown-base report if given
+func generateMarkdown(files []*coverage.FileMetrics) error {
+	opts := markdown.DefaultOptions()
+	opts.MaxFiles = *mdFiles
+	opts.MaxBytes = *mdMaxBytes
+
+	if *mdBase != "" {
+		base, err := readReport(*mdBase)
+		if err != nil {
+			return err
+		}
+		opts.Base = base
+	}
+
+	return markdown.Generate(files, *outDir, opts)
+}
+
+func generateReports(files []*coverage.FileMetrics, excluded []exclude.File, modules module.Modules, src *source.Resolver, patch *coverage.PatchMetrics, trend []history.Entry, pages func(*coverage.FileMetrics) bool) error {
+	selected := selectedFormats()
+
+	// The JSON report is always written next to the HTML report
+	if slices.Contains(selected, "html") || slices.Contains(selected, "json") {
+		if err := report.Generate(files, excluded, *outDir, modules, patch); err != nil {
+			return err
+		}
+	}
+
+	for _, format := range selected {
+		var err error
+		switch format {
+		case "html":
+			err = generateHtmlFiles(files, excluded, modules, src, patch, trend, pages)
+		case "cobertura":
+			err = cobertura.Generate(files, *outDir, modules.Main().Path, *srcRoot)
+		case "lcov":
+			err = lcov.Generate(files, *outDir, *srcRoot)
+		case "badge":
+			err = badge.Generate(files, *outDir, badgeOptions())
+		case "markdown":
+			err = generateMarkdown(files)
+		case "text":
+			err = text.Write(os.Stdout, files, textOptions())
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	printStatistics(files, patch)
+
+	return nil
+}
+
+func generateHtmlFiles(files []*coverage.FileMetrics, excluded []exclude.File, modules module.Modules, src *source.Resolver, patch *coverage.PatchMetrics, trend []history.Entry, pages func(*coverage.FileMetrics) bool) error {
+	if *singleFile {
+		return generateSingleFile(files, excluded, modules, src, patch, trend)
+	}
+
 	filesDir := filepath.Join(*outDir, "tree")
 
 	if err := file.Assets(filesDir); err != nil {
@@ -99,35 +858,155 @@ func generateHtmlFiles(files []*coverage.FileMetrics, module string) error {
 		return err
 	}
 
-	if err := index.Generate(files, *outDir, module); err != nil {
+	if err := index.Generate(listedFiles(files, patch), excluded, *outDir, modules, patch, trend); err != nil {
 		return err
 	}
 
-	for _, f := range files {
-		if err := file.Generate(f, filesDir); err != nil {
-			return err
-		}
+	errs, err := generatePages(files, filesDir, src, pages)
+	if err != nil {
+		return err
+	}
+
+	return errors.Join(errs...)
+}
 
-		path := filepath.Join(filesDir, strings.TrimSuffix(f.LocalPath, ".go")+".html")
-		printProgress(fmt.Sprintf("Generated %s", path))
+// generateSingleFile creates an index.html with all styles, scripts and
+// file pages inlined
+func generateSingleFile(files []*coverage.FileMetrics, excluded []exclude.File, modules module.Modules, src *source.Resolver, patch *coverage.PatchMetrics, trend []history.Entry) error {
+	bar := progress.New(progressWriter(), "Rendering pages", len(files))
+	rendered, errs := file.FragmentAll(files, src, runtime.GOMAXPROCS(0), func(f *coverage.FileMetrics, err error) {
+		bar.Increment(f.LocalPath)
+	})
+	bar.Finish()
+	if err := errors.Join(errs...); err != nil {
+		return err
 	}
 
-	printStatistics(files)
+	fragments := make(map[string][]byte, len(files))
+	for i, f := range files {
+		fragments[f.LocalPath] = rendered[i]
+	}
 
-	return nil
+	return index.GenerateSingle(listedFiles(files, patch), excluded, *outDir, modules, patch, trend, fragments)
 }
 
-func printProgress(message string) {
+// progressWriter returns the writer of progress output, discarding it if
+// quiet
+func progressWriter() io.Writer {
 	if *quiet {
-		return
+		return io.Discard
 	}
 
-	fmt.Printf("%s", message)
-	time.Sleep(125 * time.Millisecond)
-	fmt.Print("\x1b[2K\r")
+	return os.Stdout
 }
 
-func printStatistics(files []*coverage.FileMetrics) {
+// generatePages creates the stale file detail pages in parallel and
+// reports the progress unless quiet. Pages are stale if their source,
+// metrics or the generator changed since the run recorded in the manifest
+// of the output directory, pages of files no longer reported are deleted.
+// Files not selected by pages are left as they are. The returned errors
+// match the files.
+func generatePages(files []*coverage.FileMetrics, filesDir string, src *source.Resolver, pages func(*coverage.FileMetrics) bool) ([]error, error) {
+	manifestPath := filepath.Join(*outDir, manifest.FileName)
+	prev, err := manifest.Read(manifestPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: regenerating all pages: %v\n", err)
+		prev = manifest.New("")
+	}
+	next := manifest.New(file.Fingerprint())
+
+	hashes := make([]string, len(files))
+	var stale []*coverage.FileMetrics
+	var staleIdx []int
+	for i, f := range files {
+		if pages != nil && !pages(f) {
+			if hash, ok := prev.Pages[f.LocalPath]; ok && prev.Generator == next.Generator {
+				next.Pages[f.LocalPath] = hash
+			}
+			continue
+		}
+
+		hashes[i] = pageHash(f, src)
+		if hashes[i] != "" && prev.Unchanged(next.Generator, f.LocalPath, hashes[i]) && exists(file.PagePath(filesDir, f.LocalPath)) {
+			next.Pages[f.LocalPath] = hashes[i]
+			continue
+		}
+		stale = append(stale, f)
+		staleIdx = append(staleIdx, i)
+	}
+
+	bar := progress.New(progressWriter(), "Generating pages", len(stale))
+	staleErrs := file.GenerateAll(stale, filesDir, src, runtime.GOMAXPROCS(0), func(f *coverage.FileMetrics, err error) {
+		bar.Increment(file.PagePath(filesDir, f.LocalPath))
+	})
+	bar.Finish()
+
+	errs := make([]error, len(files))
+	for j, i := range staleIdx {
+		errs[i] = staleErrs[j]
+		if errs[i] == nil && hashes[i] != "" {
+			next.Pages[files[i].LocalPath] = hashes[i]
+		}
+	}
+
+	if skipped := len(files) - len(stale); skipped > 0 && !*quiet {
+		fmt.Printf("Skipped %d unchanged pages.\n", skipped)
+	}
+
+	removeStalePages(prev, files, filesDir)
+
+	return errs, next.Write(manifestPath)
+}
+
+// pageHash returns the input hash of a file page, or an empty hash if the
+// source cannot be read and the page is regenerated to report the error
+func pageHash(f *coverage.FileMetrics, src *source.Resolver) string {
+	source, err := src.ReadFile(f.LocalPath)
+	if err != nil {
+		return ""
+	}
+
+	hash, err := file.InputHash(f, source)
+	if err != nil {
+		return ""
+	}
+
+	return hash
+}
+
+// removeStalePages deletes the pages recorded in the manifest of files no
+// longer reported, along with directories left empty
+func removeStalePages(prev *manifest.Manifest, files []*coverage.FileMetrics, filesDir string) {
+	reported := make(map[string]bool, len(files))
+	for _, f := range files {
+		reported[f.LocalPath] = true
+	}
+
+	for localPath := range prev.Pages {
+		if reported[localPath] {
+			continue
+		}
+
+		pagePath := file.PagePath(filesDir, localPath)
+		if err := os.Remove(pagePath); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			fmt.Fprintf(os.Stderr, "Warning: failed to remove stale page: %v\n", err)
+			continue
+		}
+
+		for dir := filepath.Dir(pagePath); dir != filesDir && strings.HasPrefix(dir, filesDir); dir = filepath.Dir(dir) {
+			if os.Remove(dir) != nil {
+				break
+			}
+		}
+	}
+}
+
+func exists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+func printStatistics(files []*coverage.FileMetrics, patch *coverage.PatchMetrics) {
 	if *quiet {
 		return
 	}
@@ -140,6 +1019,35 @@ func printStatistics(files []*coverage.FileMetrics) {
 		totalMetrics.TotalStmts,
 		totalMetrics.TotalFiles,
 	)
+
+	if patch == nil {
+		return
+	}
+
+	fmt.Printf(
+		"Patch coverage against %s is %.2f%% of changed lines (%d of %d) in %d files.\n",
+		patch.Base,
+		patch.CoveragePct,
+		patch.CoveredLines,
+		patch.TrackedLines,
+		patch.TotalFiles,
+	)
+}
+
+// checkThresholds exits with exitThreshold if a threshold is not met. The
+// total, package and file thresholds apply to all files, also against a
+// diff base, only the patch threshold applies to the changed lines.
+func checkThresholds(files []*coverage.FileMetrics, patch *coverage.PatchMetrics, thresholds threshold.Thresholds) {
+	violations := threshold.Check(files, patch, thresholds)
+	if len(violations) == 0 {
+		return
+	}
+
+	fmt.Fprintf(os.Stderr, "Coverage thresholds not met (%d violations):\n", len(violations))
+	for _, v := range violations {
+		fmt.Fprintf(os.Stderr, "  - %s\n", v)
+	}
+	os.Exit(exitThreshold)
 }
 
 func checkErr(err error) {
@@ -0,0 +1,225 @@
+/*
+Copyright (c) Tobias Schäfer. All rights reserved.
+Licensed under the MIT license, see LICENSE in the project root for details.
+*/
+package coverui
+
+import (
+	"flag"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/tschaefer/cover-ui/internal/exclude"
+	"github.com/tschaefer/cover-ui/internal/generator/report"
+	"github.com/tschaefer/cover-ui/internal/history"
+	"github.com/tschaefer/cover-ui/internal/threshold"
+)
+
+const testSource = `package main
+
+func main() {
+	println("covered")
+}
+
+func other() int {
+	return 1
+}
+`
+
+const testProfile = `mode: set
+example.com/m/main.go:3.13,5.2 1 1
+example.com/m/main.go:7.18,9.2 1 0
+`
+
+// setFlags sets command line flags for the duration of a test
+func setFlags(t *testing.T, values map[string]string) {
+	for name, value := range values {
+		f := flag.Lookup(name)
+		previous := f.Value.String()
+		if err := f.Value.Set(value); err != nil {
+			t.Fatalf("Failed to set -%s: %v", name, err)
+		}
+		t.Cleanup(func() {
+			_ = f.Value.Set(previous)
+		})
+	}
+}
+
+func runGit(t *testing.T, dir string, args ...string) {
+	cmd := exec.Command("git", append([]string{"-C", dir}, args...)...)
+	cmd.Env = append(os.Environ(),
+		"GIT_AUTHOR_NAME=test", "GIT_AUTHOR_EMAIL=test@example.com",
+		"GIT_COMMITTER_NAME=test", "GIT_COMMITTER_EMAIL=test@example.com",
+	)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git %s failed: %v: %s", args[0], err, out)
+	}
+}
+
+func writeFile(t *testing.T, path, content string) {
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("Failed to write %s: %v", path, err)
+	}
+}
+
+func TestGenerateDiffBaseThresholds(t *testing.T) {
+	srcDir := t.TempDir()
+	writeFile(t, filepath.Join(srcDir, "go.mod"), "module example.com/m\n\ngo 1.25\n")
+	writeFile(t, filepath.Join(srcDir, "main.go"), testSource)
+	runGit(t, srcDir, "init", "-q")
+	runGit(t, srcDir, "add", "-A")
+	runGit(t, srcDir, "commit", "-q", "-m", "initial")
+
+	profilePath := filepath.Join(t.TempDir(), "coverage.out")
+	writeFile(t, profilePath, testProfile)
+
+	setFlags(t, map[string]string{
+		"src":       srcDir,
+		"profile":   profilePath,
+		"out":       t.TempDir(),
+		"format":    "json",
+		"diff-base": "HEAD",
+		"quiet":     "true",
+	})
+
+	files, patch, err := generate(nil, false)
+	if err != nil {
+		t.Fatalf("generate failed: %v", err)
+	}
+
+	if len(files) != 1 {
+		t.Fatalf("Expected all files despite an empty patch, got %d", len(files))
+	}
+	if patch == nil || patch.TotalFiles != 0 {
+		t.Fatalf("Expected an empty patch, got %+v", patch)
+	}
+
+	if violations := threshold.Check(files, patch, threshold.Thresholds{Total: 50}); len(violations) != 0 {
+		t.Errorf("Expected -min-total 50 to pass for 50%% total coverage, got %v", violations)
+	}
+	if violations := threshold.Check(files, patch, threshold.Thresholds{Total: 60}); len(violations) != 1 {
+		t.Errorf("Expected -min-total 60 to fail for 50%% total coverage, got %v", violations)
+	}
+}
+
+func TestGenerateUntestedRewrite(t *testing.T) {
+	srcDir := t.TempDir()
+	writeFile(t, filepath.Join(srcDir, "go.mod"), "module example.com/m\n\ngo 1.25\n")
+	writeFile(t, filepath.Join(srcDir, "main.go"), testSource)
+	writeFile(t, filepath.Join(srcDir, "util.go"), "package main\n\nfunc util() int {\n\treturn 2\n}\n")
+
+	profilePath := filepath.Join(t.TempDir(), "coverage.out")
+	writeFile(t, profilePath, "mode: set\n/ci/checkout/main.go:3.13,5.2 1 1\n/ci/checkout/main.go:7.18,9.2 1 0\n")
+
+	setFlags(t, map[string]string{
+		"src":              srcDir,
+		"profile":          profilePath,
+		"out":              t.TempDir(),
+		"format":           "json",
+		"rewrite":          "/ci/checkout=",
+		"include-untested": "true",
+		"quiet":            "true",
+	})
+
+	files, _, err := generate(nil, false)
+	if err != nil {
+		t.Fatalf("generate failed: %v", err)
+	}
+
+	if len(files) != 2 {
+		names := make([]string, 0, len(files))
+		for _, f := range files {
+			names = append(names, f.FileName)
+		}
+		t.Fatalf("Expected main.go and the untested util.go, got %v", names)
+	}
+	for _, f := range files {
+		if filepath.Base(f.FileName) == "main.go" && f.CoveredStmts == 0 {
+			t.Errorf("Expected main.go to keep its coverage, got %+v", f)
+		}
+	}
+}
+
+func TestGenerateHistoryAfterReports(t *testing.T) {
+	srcDir := t.TempDir()
+	writeFile(t, filepath.Join(srcDir, "go.mod"), "module example.com/m\n\ngo 1.25\n")
+	writeFile(t, filepath.Join(srcDir, "main.go"), testSource)
+
+	profilePath := filepath.Join(t.TempDir(), "coverage.out")
+	writeFile(t, profilePath, testProfile)
+	historyPath := filepath.Join(t.TempDir(), "history.jsonl")
+
+	setFlags(t, map[string]string{
+		"src":           srcDir,
+		"profile":       profilePath,
+		"out":           t.TempDir(),
+		"format":        "markdown",
+		"markdown-base": filepath.Join(t.TempDir(), "missing.json"),
+		"history":       historyPath,
+		"quiet":         "true",
+	})
+
+	if _, _, err := generate(nil, true); err == nil {
+		t.Fatal("Expected error for missing Markdown base, got nil")
+	}
+	if entries, _ := history.Read(historyPath); len(entries) != 0 {
+		t.Errorf("Expected no history entry of a failed run, got %d", len(entries))
+	}
+
+	setFlags(t, map[string]string{"markdown-base": ""})
+	if _, _, err := generate(nil, true); err != nil {
+		t.Fatalf("generate failed: %v", err)
+	}
+	if entries, _ := history.Read(historyPath); len(entries) != 1 {
+		t.Errorf("Expected 1 history entry, got %d", len(entries))
+	}
+}
+
+func TestSelectedFormats(t *testing.T) {
+	setFlags(t, map[string]string{"format": "html,summary,text"})
+
+	if err := validateFormats(); err != nil {
+		t.Fatalf("Expected summary to be accepted, got %v", err)
+	}
+	if got := selectedFormats(); !slices.Equal(got, []string{"html", "text"}) {
+		t.Errorf("Expected summary to select text once, got %v", got)
+	}
+}
+
+func TestGenerateIgnoredFile(t *testing.T) {
+	srcDir := t.TempDir()
+	writeFile(t, filepath.Join(srcDir, "go.mod"), "module example.com/m\n\ngo 1.25\n")
+	writeFile(t, filepath.Join(srcDir, "main.go"), testSource)
+	writeFile(t, filepath.Join(srcDir, "debug.go"), "// coverage:ignore-file\npackage main\n\nfunc debug() {\n\tprintln()\n}\n")
+
+	profilePath := filepath.Join(t.TempDir(), "coverage.out")
+	writeFile(t, profilePath, testProfile+"example.com/m/debug.go:4.14,6.2 1 0\n")
+	outDir := t.TempDir()
+
+	setFlags(t, map[string]string{
+		"src":     srcDir,
+		"profile": profilePath,
+		"out":     outDir,
+		"format":  "json",
+		"quiet":   "true",
+	})
+
+	files, _, err := generate(nil, false)
+	if err != nil {
+		t.Fatalf("generate failed: %v", err)
+	}
+	if len(files) != 1 || files[0].LocalPath != "main.go" {
+		t.Fatalf("Expected only main.go, got %d files", len(files))
+	}
+
+	r, err := report.Read(filepath.Join(outDir, report.FileName))
+	if err != nil {
+		t.Fatalf("Failed to read report: %v", err)
+	}
+	if len(r.Excluded) != 1 || r.Excluded[0].LocalPath != "debug.go" || r.Excluded[0].Reason != exclude.ReasonIgnored {
+		t.Errorf("Expected debug.go excluded as ignored, got %+v", r.Excluded)
+	}
+}
@@ -2,4 +2,7 @@ module github.com/tschaefer/cover-ui
 
 go 1.25.3
 
-require golang.org/x/tools v0.40.0
+require (
+	golang.org/x/mod v0.31.0
+	golang.org/x/tools v0.40.0
+)
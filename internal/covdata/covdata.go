@@ -0,0 +1,399 @@
+/*
+Copyright (c) Tobias Schäfer. All rights reserved.
+Licensed under the MIT license, see LICENSE in the project root for details.
+*/
+package covdata
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"golang.org/x/tools/cover"
+)
+
+// File name prefixes and magic strings of the binary coverage format
+// written by binaries built with "go build -cover" into GOCOVERDIR
+const (
+	metaFilePrefix    = "covmeta."
+	counterFilePrefix = "covcounters."
+)
+
+var (
+	metaMagic    = [4]byte{0x00, 0x63, 0x76, 0x6d}
+	counterMagic = [4]byte{0x00, 0x63, 0x77, 0x6d}
+)
+
+// Counter modes and granularities as stored in the meta-data file header
+const (
+	modeSet    = 1
+	modeCount  = 2
+	modeAtomic = 3
+
+	granularityPerFunc = 2
+)
+
+// Counter flavors as stored in the counter data file header
+const (
+	flavorRaw     = 1
+	flavorULeb128 = 2
+)
+
+// Sizes and field offsets of the fixed headers and footers
+const (
+	metaFileHeaderSize    = 56
+	metaSymbolHeaderSize  = 44
+	counterFileHeaderSize = 32
+	counterFileFooterSize = 16
+
+	counterMetaHashOffset   = 8
+	counterFlavorOffset     = 24
+	counterBigEndianOffset  = 25
+	footerNumSegmentsOffset = 8
+)
+
+// supportedVersion is the latest known meta-data and counter file version
+const supportedVersion = 1
+
+// unit is a coverable unit (basic block) of a function
+type unit struct {
+	startLine, startCol int
+	endLine, endCol     int
+	numStmt             int
+}
+
+// function holds the meta-data of a single instrumented function
+type function struct {
+	srcFile string
+	units   []unit
+}
+
+// metaFile holds the decoded content of a covmeta file
+type metaFile struct {
+	hash        [16]byte
+	mode        string
+	perFunc     bool
+	packages    [][]function
+	counterData map[funcKey][]uint32
+}
+
+// funcKey identifies a function by package and function index
+type funcKey struct {
+	pkg, fn uint32
+}
+
+// Read decodes all meta-data and counter data files found in dir and
+// returns their content as text format coverage profiles.
+func Read(dir string) ([]*cover.Profile, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read coverage directory: %w", err)
+	}
+
+	metas := make(map[[16]byte]*metaFile)
+	var counterFiles []string
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+
+		path := filepath.Join(dir, entry.Name())
+		switch {
+		case strings.HasPrefix(entry.Name(), metaFilePrefix):
+			meta, err := readMetaFile(path)
+			if err != nil {
+				return nil, err
+			}
+			metas[meta.hash] = meta
+		case strings.HasPrefix(entry.Name(), counterFilePrefix):
+			counterFiles = append(counterFiles, path)
+		}
+	}
+
+	if len(metas) == 0 {
+		return nil, fmt.Errorf("no coverage meta-data files found in %s", dir)
+	}
+
+	for _, path := range counterFiles {
+		if err := readCounterFile(path, metas); err != nil {
+			return nil, err
+		}
+	}
+
+	hashes := make([][16]byte, 0, len(metas))
+	for hash := range metas {
+		hashes = append(hashes, hash)
+	}
+	sort.Slice(hashes, func(i, j int) bool {
+		return string(hashes[i][:]) < string(hashes[j][:])
+	})
+
+	var profiles []*cover.Profile
+	for _, hash := range hashes {
+		profiles = append(profiles, metas[hash].profiles()...)
+	}
+
+	return profiles, nil
+}
+
+// readMetaFile decodes a covmeta file with the meta-data of all
+// instrumented packages of a binary
+func readMetaFile(path string) (*metaFile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read meta-data file: %w", err)
+	}
+
+	r := &reader{data: data}
+	if r.magic() != metaMagic {
+		return nil, fmt.Errorf("invalid meta-data file %s", path)
+	}
+	if version := r.uint32(); version > supportedVersion {
+		return nil, fmt.Errorf("unsupported meta-data file version %d in %s", version, path)
+	}
+	_ = r.uint64()
+	numPackages := r.uint64()
+
+	meta := &metaFile{counterData: make(map[funcKey][]uint32)}
+	copy(meta.hash[:], r.bytes(16))
+	_ = r.uint32()
+	_ = r.uint32()
+
+	switch r.uint8() {
+	case modeSet:
+		meta.mode = "set"
+	case modeCount:
+		meta.mode = "count"
+	case modeAtomic:
+		meta.mode = "atomic"
+	default:
+		return nil, fmt.Errorf("unsupported counter mode in %s", path)
+	}
+	meta.perFunc = r.uint8() == granularityPerFunc
+	r.seek(metaFileHeaderSize)
+
+	// Every package has an offset and a length
+	n := r.count(numPackages, 16, "package count")
+	offsets := make([]uint64, n)
+	for i := range offsets {
+		offsets[i] = r.uint64()
+	}
+	lengths := make([]uint64, n)
+	for i := range lengths {
+		lengths[i] = r.uint64()
+	}
+	if r.err != nil {
+		return nil, fmt.Errorf("failed to decode meta-data file %s: %w", path, r.err)
+	}
+
+	for i := range offsets {
+		end := offsets[i] + lengths[i]
+		if end > uint64(len(data)) {
+			return nil, fmt.Errorf("failed to decode meta-data file %s: package %d out of range", path, i)
+		}
+
+		functions, err := readPackage(data[offsets[i]:end])
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode meta-data file %s: %w", path, err)
+		}
+		meta.packages = append(meta.packages, functions)
+	}
+
+	return meta, nil
+}
+
+// readPackage decodes the meta-data blob of a single package
+func readPackage(data []byte) ([]function, error) {
+	r := &reader{data: data}
+	r.seek(metaSymbolHeaderSize - 4)
+	numFuncs := r.count(uint64(r.uint32()), 4, "function count")
+
+	offsets := make([]uint32, numFuncs)
+	for i := range offsets {
+		offsets[i] = r.uint32()
+	}
+	strtab := r.stringTable()
+
+	functions := make([]function, numFuncs)
+	for i, offset := range offsets {
+		r.seek(int(offset))
+		numUnits := r.uleb128()
+		_ = r.uleb128()
+		fileIdx := r.uleb128()
+		// Every unit has five ULEB128 values of at least one byte
+		numUnits = uint64(r.count(numUnits, 5, "unit count"))
+		if r.err == nil && fileIdx >= uint64(len(strtab)) {
+			return nil, fmt.Errorf("invalid string table reference %d", fileIdx)
+		}
+		if r.err != nil {
+			return nil, r.err
+		}
+
+		fn := function{srcFile: strtab[fileIdx]}
+		for range numUnits {
+			fn.units = append(fn.units, unit{
+				startLine: int(r.uleb128()),
+				startCol:  int(r.uleb128()),
+				endLine:   int(r.uleb128()),
+				endCol:    int(r.uleb128()),
+				numStmt:   int(r.uleb128()),
+			})
+		}
+		functions[i] = fn
+	}
+
+	if r.err != nil {
+		return nil, r.err
+	}
+
+	return functions, nil
+}
+
+// readCounterFile decodes a covcounters file and adds its counters to the
+// meta-data file it belongs to
+func readCounterFile(path string, metas map[[16]byte]*metaFile) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read counter data file: %w", err)
+	}
+
+	r := &reader{data: data}
+	if r.magic() != counterMagic {
+		return fmt.Errorf("invalid counter data file %s", path)
+	}
+	if version := r.uint32(); version > supportedVersion {
+		return fmt.Errorf("unsupported counter data file version %d in %s", version, path)
+	}
+
+	var hash [16]byte
+	r.seek(counterMetaHashOffset)
+	copy(hash[:], r.bytes(16))
+	r.seek(counterFlavorOffset)
+	flavor := r.uint8()
+	r.seek(counterBigEndianOffset)
+	r.bigEndian = r.uint8() != 0
+
+	// Orphaned counter files of other binaries are ignored, as done by
+	// "go tool covdata"
+	meta, ok := metas[hash]
+	if !ok {
+		return nil
+	}
+
+	if flavor != flavorRaw && flavor != flavorULeb128 {
+		return fmt.Errorf("unsupported counter flavor %d in %s", flavor, path)
+	}
+
+	r.seek(len(data) - counterFileFooterSize)
+	if r.magic() != counterMagic {
+		return fmt.Errorf("invalid counter data file footer in %s", path)
+	}
+	r.seek(len(data) - counterFileFooterSize + footerNumSegmentsOffset)
+	numSegments := r.uint32()
+
+	// Counters take at least one byte ULEB128 encoded, four bytes raw
+	counterSize := 4
+	if flavor == flavorULeb128 {
+		counterSize = 1
+	}
+
+	r.seek(counterFileHeaderSize)
+	// Every segment has a header of a function count and two lengths
+	numSegments = uint32(r.count(uint64(numSegments), 16, "segment count"))
+	for range numSegments {
+		numFuncs := r.uint64()
+		strTabLen := r.uint32()
+		argsLen := r.uint32()
+		r.seek(r.pos + int(strTabLen) + int(argsLen))
+
+		// Every function has a counter count, a package and a function index
+		numFuncs = uint64(r.count(numFuncs, 3*counterSize, "function count"))
+		for range numFuncs {
+			numCounters := r.counter(flavor)
+			key := funcKey{pkg: r.counter(flavor), fn: r.counter(flavor)}
+
+			counters := make([]uint32, r.count(uint64(numCounters), counterSize, "counter count"))
+			for i := range counters {
+				counters[i] = r.counter(flavor)
+			}
+			if r.err != nil {
+				break
+			}
+			meta.addCounters(key, counters)
+		}
+
+		r.seek(r.pos + counterFileFooterSize)
+	}
+
+	if r.err != nil {
+		return fmt.Errorf("failed to decode counter data file %s: %w", path, r.err)
+	}
+
+	return nil
+}
+
+// addCounters folds the counters of a function into the already collected
+// ones, respecting the counter mode
+func (m *metaFile) addCounters(key funcKey, counters []uint32) {
+	existing, ok := m.counterData[key]
+	if !ok || len(existing) != len(counters) {
+		m.counterData[key] = counters
+		return
+	}
+
+	for i, c := range counters {
+		if m.mode == "set" {
+			existing[i] |= c
+			continue
+		}
+		existing[i] += c
+	}
+}
+
+// profiles converts the meta-data and collected counters into one text
+// format profile per source file
+func (m *metaFile) profiles() []*cover.Profile {
+	byFile := make(map[string]*cover.Profile)
+	var files []string
+
+	for pkgIdx, functions := range m.packages {
+		for fnIdx, fn := range functions {
+			p, ok := byFile[fn.srcFile]
+			if !ok {
+				p = &cover.Profile{FileName: fn.srcFile, Mode: m.mode}
+				byFile[fn.srcFile] = p
+				files = append(files, fn.srcFile)
+			}
+
+			counters := m.counterData[funcKey{pkg: uint32(pkgIdx), fn: uint32(fnIdx)}]
+			for i, u := range fn.units {
+				count := 0
+				switch {
+				case m.perFunc && len(counters) > 0:
+					count = int(counters[0])
+				case i < len(counters):
+					count = int(counters[i])
+				}
+
+				p.Blocks = append(p.Blocks, cover.ProfileBlock{
+					StartLine: u.startLine,
+					StartCol:  u.startCol,
+					EndLine:   u.endLine,
+					EndCol:    u.endCol,
+					NumStmt:   u.numStmt,
+					Count:     count,
+				})
+			}
+		}
+	}
+
+	sort.Strings(files)
+	profiles := make([]*cover.Profile, 0, len(files))
+	for _, file := range files {
+		profiles = append(profiles, byFile[file])
+	}
+
+	return profiles
+}
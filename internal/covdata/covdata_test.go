@@ -0,0 +1,244 @@
+/*
+Copyright (c) Tobias Schäfer. All rights reserved.
+Licensed under the MIT license, see LICENSE in the project root for details.
+*/
+package covdata
+
+import (
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var metaHash = [16]byte{0xca, 0xfe}
+
+func uleb(b []byte, v uint64) []byte {
+	for {
+		c := byte(v & 0x7f)
+		v >>= 7
+		if v != 0 {
+			b = append(b, c|0x80)
+			continue
+		}
+		return append(b, c)
+	}
+}
+
+func stringTable(strs ...string) []byte {
+	b := uleb(nil, uint64(len(strs)))
+	for _, s := range strs {
+		b = uleb(b, uint64(len(s)))
+		b = append(b, s...)
+	}
+	return b
+}
+
+// writeMetaFile writes a meta-data file with one package holding the
+// function "Small" in p.go with three units
+func writeMetaFile(t *testing.T, dir string, mode byte) {
+	strtab := stringTable("github.com/test/repo/p.go", "Small")
+
+	fn := uleb(nil, 3)
+	fn = uleb(fn, 1)
+	fn = uleb(fn, 0)
+	for _, u := range [][5]uint64{{4, 2, 4, 12, 1}, {5, 3, 6, 1, 1}, {7, 2, 7, 14, 2}} {
+		for _, v := range u {
+			fn = uleb(fn, v)
+		}
+	}
+	fn = uleb(fn, 0)
+
+	pkg := make([]byte, metaSymbolHeaderSize)
+	pkg = binary.LittleEndian.AppendUint32(pkg, uint32(metaSymbolHeaderSize+4+len(strtab)))
+	pkg = append(pkg, strtab...)
+	pkg = append(pkg, fn...)
+	binary.LittleEndian.PutUint32(pkg[0:], uint32(len(pkg)))
+	binary.LittleEndian.PutUint32(pkg[metaSymbolHeaderSize-8:], 1)
+	binary.LittleEndian.PutUint32(pkg[metaSymbolHeaderSize-4:], 1)
+
+	data := append([]byte{}, metaMagic[:]...)
+	data = binary.LittleEndian.AppendUint32(data, 1)
+	data = binary.LittleEndian.AppendUint64(data, 0)
+	data = binary.LittleEndian.AppendUint64(data, 1)
+	data = append(data, metaHash[:]...)
+	data = binary.LittleEndian.AppendUint32(data, 0)
+	data = binary.LittleEndian.AppendUint32(data, 0)
+	data = append(data, mode, 1, 0, 0, 0, 0, 0, 0)
+	data = binary.LittleEndian.AppendUint64(data, uint64(len(data)+16))
+	data = binary.LittleEndian.AppendUint64(data, uint64(len(pkg)))
+	data = append(data, pkg...)
+
+	path := filepath.Join(dir, "covmeta.cafe")
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatalf("Failed to write meta-data file: %v", err)
+	}
+}
+
+// writeCounterFile writes a ULEB128 counter data file with one segment
+// holding the counters of function 0 in package 0
+func writeCounterFile(t *testing.T, dir, name string, counters ...uint64) {
+	data := append([]byte{}, counterMagic[:]...)
+	data = binary.LittleEndian.AppendUint32(data, 1)
+	data = append(data, metaHash[:]...)
+	data = append(data, flavorULeb128, 0, 0, 0, 0, 0, 0, 0)
+
+	strtab := stringTable("")
+	args := uleb(nil, 0)
+	for (len(strtab)+len(args))%4 != 0 {
+		args = append(args, 0)
+	}
+	data = binary.LittleEndian.AppendUint64(data, 1)
+	data = binary.LittleEndian.AppendUint32(data, uint32(len(strtab)))
+	data = binary.LittleEndian.AppendUint32(data, uint32(len(args)))
+	data = append(data, strtab...)
+	data = append(data, args...)
+
+	data = uleb(data, uint64(len(counters)))
+	data = uleb(data, 0)
+	data = uleb(data, 0)
+	for _, c := range counters {
+		data = uleb(data, c)
+	}
+
+	data = append(data, counterMagic[:]...)
+	data = binary.LittleEndian.AppendUint32(data, 0)
+	data = binary.LittleEndian.AppendUint32(data, 1)
+	data = binary.LittleEndian.AppendUint32(data, 0)
+
+	if err := os.WriteFile(filepath.Join(dir, name), data, 0o644); err != nil {
+		t.Fatalf("Failed to write counter data file: %v", err)
+	}
+}
+
+func TestRead(t *testing.T) {
+	dir := t.TempDir()
+	writeMetaFile(t, dir, modeCount)
+	writeCounterFile(t, dir, "covcounters.cafe.1.1", 3, 0, 1)
+	writeCounterFile(t, dir, "covcounters.cafe.2.2", 2, 0, 4)
+
+	profiles, err := Read(dir)
+	if err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+
+	if len(profiles) != 1 {
+		t.Fatalf("Expected 1 profile, got %d", len(profiles))
+	}
+
+	p := profiles[0]
+	if p.FileName != "github.com/test/repo/p.go" {
+		t.Errorf("Expected FileName github.com/test/repo/p.go, got %s", p.FileName)
+	}
+	if p.Mode != "count" {
+		t.Errorf("Expected Mode count, got %s", p.Mode)
+	}
+	if len(p.Blocks) != 3 {
+		t.Fatalf("Expected 3 blocks, got %d", len(p.Blocks))
+	}
+
+	want := []struct{ line, stmts, count int }{{4, 1, 5}, {5, 1, 0}, {7, 2, 5}}
+	for i, w := range want {
+		b := p.Blocks[i]
+		if b.StartLine != w.line || b.NumStmt != w.stmts || b.Count != w.count {
+			t.Errorf("Expected block %d at line %d with %d stmts and count %d, got %+v", i, w.line, w.stmts, w.count, b)
+		}
+	}
+}
+
+func TestReadSetMode(t *testing.T) {
+	dir := t.TempDir()
+	writeMetaFile(t, dir, modeSet)
+	writeCounterFile(t, dir, "covcounters.cafe.1.1", 1, 0, 0)
+	writeCounterFile(t, dir, "covcounters.cafe.2.2", 1, 1, 0)
+
+	profiles, err := Read(dir)
+	if err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+
+	want := []int{1, 1, 0}
+	for i, b := range profiles[0].Blocks {
+		if b.Count != want[i] {
+			t.Errorf("Expected block %d count %d, got %d", i, want[i], b.Count)
+		}
+	}
+}
+
+func TestReadWithoutCounters(t *testing.T) {
+	dir := t.TempDir()
+	writeMetaFile(t, dir, modeSet)
+
+	profiles, err := Read(dir)
+	if err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+
+	for _, b := range profiles[0].Blocks {
+		if b.Count != 0 {
+			t.Errorf("Expected uncovered block, got count %d", b.Count)
+		}
+	}
+}
+
+func TestReadEmptyDir(t *testing.T) {
+	_, err := Read(t.TempDir())
+	if err == nil {
+		t.Fatal("Expected error for directory without meta-data files, got nil")
+	}
+}
+
+func TestReadInvalidMetaFile(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "covmeta.bad"), []byte("garbage"), 0o644); err != nil {
+		t.Fatalf("Failed to write meta-data file: %v", err)
+	}
+
+	_, err := Read(dir)
+	if err == nil {
+		t.Fatal("Expected error for invalid meta-data file, got nil")
+	}
+}
+
+// corrupt overwrites bytes of a written file at offset
+func corrupt(t *testing.T, path string, offset int, b ...byte) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read file: %v", err)
+	}
+	copy(data[offset:], b)
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+}
+
+func TestReadCorruptCounts(t *testing.T) {
+	huge := binary.LittleEndian.AppendUint64(nil, 1<<62)
+	tests := []struct {
+		name    string
+		corrupt func(t *testing.T, dir string)
+	}{
+		{"package count", func(t *testing.T, dir string) {
+			corrupt(t, filepath.Join(dir, "covmeta.cafe"), 16, huge...)
+		}},
+		{"function count", func(t *testing.T, dir string) {
+			corrupt(t, filepath.Join(dir, "covmeta.cafe"), metaFileHeaderSize+16+metaSymbolHeaderSize-4, 0xff, 0xff, 0xff, 0xff)
+		}},
+		{"counter count", func(t *testing.T, dir string) {
+			corrupt(t, filepath.Join(dir, "covcounters.cafe.1.1"), counterFileHeaderSize+16+4, 100)
+		}},
+	}
+
+	for _, tt := range tests {
+		dir := t.TempDir()
+		writeMetaFile(t, dir, modeCount)
+		writeCounterFile(t, dir, "covcounters.cafe.1.1", 3, 0, 1)
+		tt.corrupt(t, dir)
+
+		_, err := Read(dir)
+		if err == nil || !strings.Contains(err.Error(), "failed to parse "+tt.name) {
+			t.Errorf("Expected error for corrupt %s, got %v", tt.name, err)
+		}
+	}
+}
@@ -0,0 +1,137 @@
+/*
+Copyright (c) Tobias Schäfer. All rights reserved.
+Licensed under the MIT license, see LICENSE in the project root for details.
+*/
+package covdata
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+var errShortRead = errors.New("unexpected end of data")
+
+// reader decodes little endian integers, ULEB128 values and string tables
+// from a byte slice. The first error is sticky, all later reads return
+// zero values.
+type reader struct {
+	data      []byte
+	pos       int
+	bigEndian bool
+	err       error
+}
+
+// seek moves the read position to an absolute offset
+func (r *reader) seek(pos int) {
+	if pos < 0 || pos > len(r.data) {
+		r.fail()
+		return
+	}
+	r.pos = pos
+}
+
+// bytes reads n raw bytes
+func (r *reader) bytes(n int) []byte {
+	if r.err != nil || n < 0 || r.pos+n > len(r.data) {
+		r.fail()
+		return make([]byte, n)
+	}
+
+	b := r.data[r.pos : r.pos+n]
+	r.pos += n
+	return b
+}
+
+// magic reads a four byte magic string
+func (r *reader) magic() [4]byte {
+	var m [4]byte
+	copy(m[:], r.bytes(4))
+	return m
+}
+
+// uint8 reads a single byte
+func (r *reader) uint8() uint8 {
+	return r.bytes(1)[0]
+}
+
+// uint32 reads a four byte integer
+func (r *reader) uint32() uint32 {
+	return binary.LittleEndian.Uint32(r.bytes(4))
+}
+
+// uint64 reads an eight byte integer
+func (r *reader) uint64() uint64 {
+	return binary.LittleEndian.Uint64(r.bytes(8))
+}
+
+// uleb128 reads an unsigned LEB128 encoded integer
+func (r *reader) uleb128() uint64 {
+	var value uint64
+	var shift uint
+	for {
+		b := r.uint8()
+		if r.err != nil {
+			return 0
+		}
+		value |= uint64(b&0x7f) << shift
+		if b&0x80 == 0 {
+			return value
+		}
+		shift += 7
+	}
+}
+
+// counter reads a counter value in the given counter flavor, raw counters
+// are stored in the byte order of the producing machine
+func (r *reader) counter(flavor uint8) uint32 {
+	if flavor == flavorULeb128 {
+		return uint32(r.uleb128())
+	}
+	if r.bigEndian {
+		return binary.BigEndian.Uint32(r.bytes(4))
+	}
+	return r.uint32()
+}
+
+// count returns the number of entries n read from the data, failing if n
+// entries of at least size bytes each exceed the remaining data
+func (r *reader) count(n uint64, size int, name string) int {
+	if r.err != nil {
+		return 0
+	}
+	if remaining := uint64(len(r.data) - r.pos); n > remaining/uint64(size) {
+		r.err = fmt.Errorf("failed to parse %s: %d entries exceed the remaining %d bytes", name, n, remaining)
+		return 0
+	}
+
+	return int(n)
+}
+
+// stringTable reads a ULEB128 length prefixed list of strings
+func (r *reader) stringTable() []string {
+	n := r.uleb128()
+	if r.err != nil || n > uint64(len(r.data)) {
+		r.fail()
+		return nil
+	}
+
+	strs := make([]string, 0, n)
+	for range n {
+		size := r.uleb128()
+		if size > uint64(len(r.data)) {
+			r.fail()
+			return nil
+		}
+		strs = append(strs, string(r.bytes(int(size))))
+	}
+
+	return strs
+}
+
+// fail records a short read unless an error is already set
+func (r *reader) fail() {
+	if r.err == nil {
+		r.err = errShortRead
+	}
+}
@@ -7,10 +7,14 @@ package coverage
 import (
 	"fmt"
 	"math"
-	"os"
+	"path"
+	"slices"
 	"strings"
 
 	"golang.org/x/tools/cover"
+
+	"github.com/tschaefer/cover-ui/internal/module"
+	"github.com/tschaefer/cover-ui/internal/source"
 )
 
 // LineStatus represents the coverage status of a line
@@ -40,6 +44,8 @@ func (ls LineStatus) String() (string, error) {
 type FileMetrics struct {
 	FileName      string  `json:"fileName"`
 	LocalPath     string  `json:"localPath"`
+	Module        string  `json:"module,omitempty"`
+	Package       string  `json:"package,omitempty"`
 	TrackedLines  int     `json:"trackedLines"`
 	CoveredLines  int     `json:"coveredLines"`
 	PartialLines  int     `json:"partialLines"`
@@ -48,6 +54,15 @@ type FileMetrics struct {
 	TotalStmts    int     `json:"totalStmts"`
 	CoveredStmts  int     `json:"coveredStmts"`
 	PerLineStatus []int   `json:"perLineStatus"`
+	Mode          string  `json:"mode"`
+	PerLineHits   []int   `json:"perLineHits,omitempty"`
+	ChangedLines  []int   `json:"changedLines,omitempty"`
+	IgnoredLines  []int   `json:"ignoredLines,omitempty"`
+
+	NewlyCoveredLines   []int `json:"newlyCoveredLines,omitempty"`
+	NewlyUncoveredLines []int `json:"newlyUncoveredLines,omitempty"`
+
+	Functions []FunctionMetrics `json:"functions"`
 }
 
 type TotalMetrics struct {
@@ -57,17 +72,50 @@ type TotalMetrics struct {
 	CoveragePct  float64 `json:"coveragePct"`
 }
 
-// Analyze processes a coverage profile and returns file metrics
-func Analyze(p *cover.Profile, module, srcRoot string) (*FileMetrics, error) {
-	if !strings.HasPrefix(p.FileName, module) {
-		return nil, fmt.Errorf("failed to match module %s", module)
+// LocalPath returns the path of a profile file name relative to the source
+// root as used by Analyze
+func LocalPath(fileName string, modules module.Modules, src *source.Resolver) (string, error) {
+	name, rewritten := src.Rewrite(fileName)
+
+	mod, rel, ok := modules.Match(name)
+	switch {
+	case ok:
+		return mod.LocalPath(rel), nil
+	case !rewritten:
+		return "", fmt.Errorf("failed to match module of %s", name)
+	}
+
+	return name, nil
+}
+
+// Analyze processes a coverage profile and returns file metrics. The
+// profile file name is rewritten by the resolver first; names within one of
+// the modules are made relative to the source root, names of a matching
+// rewrite rule are taken as local paths. Blocks excluded by ignore
+// directives in the source neither count as statements nor track lines.
+func Analyze(p *cover.Profile, modules module.Modules, src *source.Resolver) (*FileMetrics, error) {
+	localPath, err := LocalPath(p.FileName, modules, src)
+	if err != nil {
+		return nil, err
+	}
+
+	source, err := src.ReadFile(localPath)
+	if err != nil {
+		return nil, err
 	}
 
-	localPath := strings.TrimPrefix(p.FileName, module+"/")
+	// Sources the parser rejects, e.g. generated or line directive mapped
+	// files, keep their statement coverage without ignore directives and
+	// functions
+	ranges, err := findIgnored(localPath, source)
+	if err != nil {
+		ranges = nil
+	}
+	blocks := withoutIgnored(p.Blocks, ranges)
 
-	source, err := os.ReadFile(localPath)
+	functions, err := analyzeFunctions(localPath, source, blocks)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read source file %s: %w", localPath, err)
+		functions = nil
 	}
 
 	lines := strings.Split(string(source), "\n")
@@ -80,12 +128,14 @@ func Analyze(p *cover.Profile, module, srcRoot string) (*FileMetrics, error) {
 	coveredStatements := 0
 	totalStmtsPerLine := make([]int, lineCount+1)
 	coveredStmtsPerLine := make([]int, lineCount+1)
-	for _, b := range p.Blocks {
+	hitsPerLine := make([]int, lineCount+1)
+	for _, b := range blocks {
 		for ln := b.StartLine; ln <= b.EndLine && ln <= lineCount; ln++ {
 			totalStmtsPerLine[ln] += b.NumStmt
 			if b.Count > 0 {
 				coveredStmtsPerLine[ln] += b.NumStmt
 			}
+			hitsPerLine[ln] = max(hitsPerLine[ln], b.Count)
 		}
 		totalStatements += b.NumStmt
 		if b.Count > 0 {
@@ -93,6 +143,17 @@ func Analyze(p *cover.Profile, module, srcRoot string) (*FileMetrics, error) {
 		}
 	}
 
+	// Lines of ignored ranges are reported unless still tracked by blocks
+	// starting before the range, e.g. the header of an ignored if statement
+	var ignoredLines []int
+	for ln := 1; ln <= lineCount; ln++ {
+		if totalStmtsPerLine[ln] == 0 && slices.ContainsFunc(ranges, func(r ignoreRange) bool {
+			return r.startLine <= ln && ln <= r.endLine
+		}) {
+			ignoredLines = append(ignoredLines, ln)
+		}
+	}
+
 	trackedLines := 0
 	coveredLines := 0
 	partialLines := 0
@@ -102,6 +163,7 @@ func Analyze(p *cover.Profile, module, srcRoot string) (*FileMetrics, error) {
 		total := totalStmtsPerLine[ln]
 		if total == 0 {
 			perLineStatus[ln] = -1
+			hitsPerLine[ln] = -1
 			continue
 		}
 		trackedLines++
@@ -123,17 +185,32 @@ func Analyze(p *cover.Profile, module, srcRoot string) (*FileMetrics, error) {
 		coveragePct = (float64(coveredStatements) / float64(totalStatements)) * 100.0
 	}
 
+	// Hit counts are only meaningful for count and atomic mode profiles,
+	// set mode profiles record 1 for any number of executions
+	var perLineHits []int
+	if p.Mode == "count" || p.Mode == "atomic" {
+		perLineHits = hitsPerLine
+	}
+
+	mod, pkg, _ := modules.Package(path.Dir(localPath))
+
 	return &FileMetrics{
 		FileName:      p.FileName,
 		LocalPath:     localPath,
+		Module:        mod.Path,
+		Package:       pkg,
 		TrackedLines:  trackedLines,
 		CoveredLines:  coveredLines,
 		PartialLines:  partialLines,
 		MissedLines:   missedLines,
 		CoveragePct:   round(coveragePct, 2),
 		PerLineStatus: perLineStatus,
+		Mode:          p.Mode,
+		PerLineHits:   perLineHits,
+		IgnoredLines:  ignoredLines,
 		TotalStmts:    totalStatements,
 		CoveredStmts:  coveredStatements,
+		Functions:     functions,
 	}, nil
 }
 
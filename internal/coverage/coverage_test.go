@@ -11,8 +11,13 @@ import (
 	"testing"
 
 	"golang.org/x/tools/cover"
+
+	"github.com/tschaefer/cover-ui/internal/module"
+	"github.com/tschaefer/cover-ui/internal/source"
 )
 
+var testModules = module.Modules{{Path: "example.com/mod", Dir: "."}}
+
 func createSourceFile(t *testing.T) (string, string) {
 	tmpDir := t.TempDir()
 	sourceFile := filepath.Join(tmpDir, "test.go")
@@ -29,10 +34,10 @@ func main() {
 }
 
 func TestAnalyze(t *testing.T) {
-	sourceFile, tmpDir := createSourceFile(t)
+	_, tmpDir := createSourceFile(t)
 
 	profile := &cover.Profile{
-		FileName: sourceFile,
+		FileName: "example.com/mod/test.go",
 		Mode:     "set",
 		Blocks: []cover.ProfileBlock{
 			{
@@ -46,7 +51,7 @@ func TestAnalyze(t *testing.T) {
 		},
 	}
 
-	metrics, err := Analyze(profile, "/", tmpDir)
+	metrics, err := Analyze(profile, testModules, source.New(tmpDir))
 	if err != nil {
 		t.Fatalf("Analyze failed: %v", err)
 	}
@@ -54,12 +59,15 @@ func TestAnalyze(t *testing.T) {
 	if metrics == nil {
 		t.Fatal("Expected non-nil metrics")
 	}
-	if metrics.FileName != sourceFile {
-		t.Errorf("Expected FileName %s, got %s", sourceFile, metrics.FileName)
+	if metrics.FileName != profile.FileName {
+		t.Errorf("Expected FileName %s, got %s", profile.FileName, metrics.FileName)
 	}
-	if metrics.LocalPath != filepath.Join(tmpDir, "test.go") {
+	if metrics.LocalPath != "test.go" {
 		t.Errorf("Expected LocalPath test.go, got %s", metrics.LocalPath)
 	}
+	if metrics.Module != "example.com/mod" || metrics.Package != "example.com/mod" {
+		t.Errorf("Expected module and package example.com/mod, got %s and %s", metrics.Module, metrics.Package)
+	}
 	if metrics.TrackedLines != 3 {
 		t.Errorf("Expected TrackedLines 3, got %d", metrics.TrackedLines)
 	}
@@ -92,32 +100,60 @@ func TestModuleMismatch(t *testing.T) {
 		Blocks:   []cover.ProfileBlock{},
 	}
 
-	_, err := Analyze(profile, "/different/module", tmpDir)
+	modules := module.Modules{{Path: "/different/module", Dir: "."}}
+	_, err := Analyze(profile, modules, source.New(tmpDir))
 	if err == nil {
 		t.Fatal("Expected error due to module mismatch, got nil")
 	}
-	if err.Error() != "failed to match module /different/module" {
+	if err.Error() != "failed to match module of "+sourceFile {
 		t.Errorf("Unexpected error message: %v", err)
 	}
 }
 
 func TestSourceReadError(t *testing.T) {
 	profile := &cover.Profile{
-		FileName: "/non/existent/file.go",
+		FileName: "example.com/mod/non/existent/file.go",
 		Mode:     "set",
 		Blocks:   []cover.ProfileBlock{},
 	}
 
-	_, err := Analyze(profile, "/", ".")
+	_, err := Analyze(profile, testModules, source.New("."))
 	if err == nil {
 		t.Fatal("Expected error due to file read failure, got nil")
 	}
-	expectedPrefix := "failed to read source file /non/existent/file.go"
+	expectedPrefix := "failed to read source file non/existent/file.go"
 	if !strings.HasPrefix(err.Error(), expectedPrefix) {
 		t.Errorf("Unexpected error message: %v", err)
 	}
 }
 
+func TestAnalyzeParseError(t *testing.T) {
+	tmpDir := t.TempDir()
+	content := "package main\n\nfunc main() {\n\tprintln(\"hello\")\n}\n\nfunc broken( {\n"
+	if err := os.WriteFile(filepath.Join(tmpDir, "test.go"), []byte(content), 0o644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	profile := &cover.Profile{
+		FileName: "example.com/mod/test.go",
+		Mode:     "set",
+		Blocks: []cover.ProfileBlock{
+			{StartLine: 3, StartCol: 13, EndLine: 5, EndCol: 2, NumStmt: 1, Count: 1},
+		},
+	}
+
+	metrics, err := Analyze(profile, testModules, source.New(tmpDir))
+	if err != nil {
+		t.Fatalf("Expected unparsable source to be analyzed, got %v", err)
+	}
+	if metrics.TotalStmts != 1 || metrics.CoveredStmts != 1 {
+		t.Errorf("Expected 1 of 1 statements covered, got %d of %d", metrics.CoveredStmts, metrics.TotalStmts)
+	}
+	if len(metrics.Functions) != 0 || len(metrics.IgnoredLines) != 0 {
+		t.Errorf("Expected no functions and ignored lines, got %v and %v", metrics.Functions, metrics.IgnoredLines)
+	}
+}
+
 func TestLineStatus(t *testing.T) {
 	tests := []struct {
 		name   string
@@ -166,3 +202,119 @@ func TestLineStatusString(t *testing.T) {
 		t.Fatal("Expected error for invalid LineStatus, got nil")
 	}
 }
+
+func TestAnalyzeHits(t *testing.T) {
+	_, tmpDir := createSourceFile(t)
+
+	profile := &cover.Profile{
+		FileName: "example.com/mod/test.go",
+		Mode:     "count",
+		Blocks: []cover.ProfileBlock{
+			{StartLine: 3, StartCol: 13, EndLine: 4, EndCol: 18, NumStmt: 1, Count: 7},
+			{StartLine: 4, StartCol: 18, EndLine: 5, EndCol: 2, NumStmt: 1, Count: 0},
+		},
+	}
+
+	metrics, err := Analyze(profile, testModules, source.New(tmpDir))
+	if err != nil {
+		t.Fatalf("Analyze failed: %v", err)
+	}
+
+	want := []int{0, -1, -1, 7, 7, 0}
+	if len(metrics.PerLineHits) != len(want) {
+		t.Fatalf("Expected %d hit entries, got %d", len(want), len(metrics.PerLineHits))
+	}
+	for ln := 1; ln < len(want); ln++ {
+		if metrics.PerLineHits[ln] != want[ln] {
+			t.Errorf("Expected line %d hits %d, got %d", ln, want[ln], metrics.PerLineHits[ln])
+		}
+	}
+
+	profile.Mode = "set"
+	metrics, err = Analyze(profile, testModules, source.New(tmpDir))
+	if err != nil {
+		t.Fatalf("Analyze failed: %v", err)
+	}
+	if metrics.PerLineHits != nil {
+		t.Errorf("Expected no hit counts for set mode, got %v", metrics.PerLineHits)
+	}
+}
+
+func TestAnalyzeSourceRoot(t *testing.T) {
+	sourceFile, tmpDir := createSourceFile(t)
+	blocks := []cover.ProfileBlock{
+		{StartLine: 3, StartCol: 13, EndLine: 5, EndCol: 2, NumStmt: 2, Count: 1},
+	}
+
+	tests := []struct {
+		name     string
+		fileName string
+		resolver *source.Resolver
+	}{
+		{"module path", "example.com/mod/test.go", source.New(tmpDir)},
+		{"rewritten path", "/ci/checkout/test.go", source.New(tmpDir, source.Rewrite{From: "/ci/checkout"})},
+		{"rewritten module", "example.com/fork/test.go", source.New(tmpDir, source.Rewrite{From: "example.com/fork", To: "example.com/mod"})},
+	}
+
+	for _, tt := range tests {
+		profile := &cover.Profile{FileName: tt.fileName, Mode: "set", Blocks: blocks}
+
+		metrics, err := Analyze(profile, testModules, tt.resolver)
+		if err != nil {
+			t.Fatalf("%s: Analyze failed: %v", tt.name, err)
+		}
+		if metrics.LocalPath != "test.go" {
+			t.Errorf("%s: Expected LocalPath test.go, got %s", tt.name, metrics.LocalPath)
+		}
+		if metrics.FileName != tt.fileName {
+			t.Errorf("%s: Expected FileName %s, got %s", tt.name, tt.fileName, metrics.FileName)
+		}
+	}
+
+	resolver := source.New(filepath.Dir(tmpDir))
+	profile := &cover.Profile{FileName: "example.com/mod/test.go", Mode: "set", Blocks: blocks}
+	if _, err := Analyze(profile, testModules, resolver); err == nil {
+		t.Fatal("Expected error for source outside the source root, got nil")
+	}
+
+	missing := resolver.Missing()
+	if len(missing) != 1 || missing[0].Name != "test.go" || missing[0].Path == sourceFile {
+		t.Errorf("Expected test.go to be reported missing, got %v", missing)
+	}
+}
+
+func TestAnalyzeNestedModule(t *testing.T) {
+	sourceFile, tmpDir := createSourceFile(t)
+	nestedDir := filepath.Join(tmpDir, "tools", "gen")
+	if err := os.MkdirAll(nestedDir, 0o755); err != nil {
+		t.Fatalf("Failed to create directory: %v", err)
+	}
+	if err := os.Rename(sourceFile, filepath.Join(nestedDir, "test.go")); err != nil {
+		t.Fatalf("Failed to move test file: %v", err)
+	}
+
+	modules := module.Modules{
+		{Path: "example.com/mod", Dir: "."},
+		{Path: "example.com/tools", Dir: "tools"},
+	}
+	profile := &cover.Profile{
+		FileName: "example.com/tools/gen/test.go",
+		Mode:     "set",
+		Blocks:   []cover.ProfileBlock{{StartLine: 3, StartCol: 13, EndLine: 5, EndCol: 2, NumStmt: 2, Count: 1}},
+	}
+
+	metrics, err := Analyze(profile, modules, source.New(tmpDir))
+	if err != nil {
+		t.Fatalf("Analyze failed: %v", err)
+	}
+
+	if metrics.LocalPath != "tools/gen/test.go" {
+		t.Errorf("Expected LocalPath tools/gen/test.go, got %s", metrics.LocalPath)
+	}
+	if metrics.Module != "example.com/tools" {
+		t.Errorf("Expected module example.com/tools, got %s", metrics.Module)
+	}
+	if metrics.Package != "example.com/tools/gen" {
+		t.Errorf("Expected package example.com/tools/gen, got %s", metrics.Package)
+	}
+}
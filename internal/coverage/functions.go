@@ -0,0 +1,156 @@
+/*
+Copyright (c) Tobias Schäfer. All rights reserved.
+Licensed under the MIT license, see LICENSE in the project root for details.
+*/
+package coverage
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"sort"
+
+	"golang.org/x/tools/cover"
+)
+
+// FunctionMetrics holds coverage metrics for a single function or method
+type FunctionMetrics struct {
+	Name         string  `json:"name"`
+	Receiver     string  `json:"receiver,omitempty"`
+	StartLine    int     `json:"startLine"`
+	EndLine      int     `json:"endLine"`
+	TotalStmts   int     `json:"totalStmts"`
+	CoveredStmts int     `json:"coveredStmts"`
+	CoveragePct  float64 `json:"coveragePct"`
+}
+
+// QualifiedName returns the function name, prefixed with the receiver
+// type for methods, e.g. "(*Server).Start"
+func (f FunctionMetrics) QualifiedName() string {
+	if f.Receiver == "" {
+		return f.Name
+	}
+
+	return "(" + f.Receiver + ")." + f.Name
+}
+
+// funcExtent describes the source range of a function declaration
+type funcExtent struct {
+	name      string
+	receiver  string
+	startLine int
+	startCol  int
+	endLine   int
+	endCol    int
+}
+
+// analyzeFunctions parses the source and attributes the profile blocks to
+// the enclosing function declarations. Blocks of function literals count
+// towards the declaration they are defined in.
+func analyzeFunctions(fileName string, source []byte, blocks []cover.ProfileBlock) ([]FunctionMetrics, error) {
+	extents, err := findFunctions(fileName, source)
+	if err != nil {
+		return nil, err
+	}
+
+	sorted := make([]cover.ProfileBlock, len(blocks))
+	copy(sorted, blocks)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].StartLine < sorted[j].StartLine ||
+			sorted[i].StartLine == sorted[j].StartLine && sorted[i].StartCol < sorted[j].StartCol
+	})
+
+	functions := make([]FunctionMetrics, 0, len(extents))
+	for _, e := range extents {
+		total := 0
+		covered := 0
+		for _, b := range sorted {
+			if b.StartLine > e.endLine || (b.StartLine == e.endLine && b.StartCol >= e.endCol) {
+				break
+			}
+			if b.EndLine < e.startLine || (b.EndLine == e.startLine && b.EndCol <= e.startCol) {
+				continue
+			}
+			total += b.NumStmt
+			if b.Count > 0 {
+				covered += b.NumStmt
+			}
+		}
+
+		coveragePct := 0.0
+		if total > 0 {
+			coveragePct = (float64(covered) / float64(total)) * 100.0
+		}
+
+		functions = append(functions, FunctionMetrics{
+			Name:         e.name,
+			Receiver:     e.receiver,
+			StartLine:    e.startLine,
+			EndLine:      e.endLine,
+			TotalStmts:   total,
+			CoveredStmts: covered,
+			CoveragePct:  round(coveragePct, 2),
+		})
+	}
+
+	return functions, nil
+}
+
+// findFunctions returns the extents of all function declarations with a body
+func findFunctions(fileName string, source []byte) ([]funcExtent, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, fileName, source, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse source file %s: %w", fileName, err)
+	}
+
+	var extents []funcExtent
+	for _, decl := range file.Decls {
+		fn, ok := decl.(*ast.FuncDecl)
+		if !ok || fn.Body == nil {
+			continue
+		}
+
+		start := fset.Position(fn.Pos())
+		end := fset.Position(fn.End())
+		extents = append(extents, funcExtent{
+			name:      fn.Name.Name,
+			receiver:  receiverType(fn),
+			startLine: start.Line,
+			startCol:  start.Column,
+			endLine:   end.Line,
+			endCol:    end.Column,
+		})
+	}
+
+	return extents, nil
+}
+
+// receiverType returns the receiver type of a method, e.g. "*Server", or an
+// empty string for plain functions
+func receiverType(fn *ast.FuncDecl) string {
+	if fn.Recv == nil || len(fn.Recv.List) == 0 {
+		return ""
+	}
+
+	return typeName(fn.Recv.List[0].Type)
+}
+
+// typeName renders a receiver type expression without type parameters
+func typeName(expr ast.Expr) string {
+	switch t := expr.(type) {
+	case *ast.StarExpr:
+		return "*" + typeName(t.X)
+	case *ast.IndexExpr:
+		return typeName(t.X)
+	case *ast.IndexListExpr:
+		return typeName(t.X)
+	case *ast.ParenExpr:
+		return typeName(t.X)
+	case *ast.Ident:
+		return t.Name
+	}
+
+	return ""
+}
@@ -0,0 +1,84 @@
+/*
+Copyright (c) Tobias Schäfer. All rights reserved.
+Licensed under the MIT license, see LICENSE in the project root for details.
+*/
+package coverage
+
+import (
+	"testing"
+
+	"golang.org/x/tools/cover"
+)
+
+const functionsSource = `package main
+
+type Server[T any] struct{}
+
+func (s *Server[T]) Start() {
+	go func() {
+		println("started")
+	}()
+}
+
+func helper(x int) int {
+	if x > 0 {
+		return x
+	}
+	return -x
+}
+`
+
+func TestAnalyzeFunctions(t *testing.T) {
+	blocks := []cover.ProfileBlock{
+		{StartLine: 5, StartCol: 29, EndLine: 6, EndCol: 12, NumStmt: 1, Count: 1},
+		{StartLine: 6, StartCol: 12, EndLine: 8, EndCol: 3, NumStmt: 1, Count: 0},
+		{StartLine: 11, StartCol: 24, EndLine: 12, EndCol: 11, NumStmt: 1, Count: 1},
+		{StartLine: 12, StartCol: 11, EndLine: 14, EndCol: 3, NumStmt: 1, Count: 1},
+		{StartLine: 15, StartCol: 2, EndLine: 15, EndCol: 11, NumStmt: 1, Count: 0},
+	}
+
+	functions, err := analyzeFunctions("main.go", []byte(functionsSource), blocks)
+	if err != nil {
+		t.Fatalf("analyzeFunctions failed: %v", err)
+	}
+
+	if len(functions) != 2 {
+		t.Fatalf("Expected 2 functions, got %d", len(functions))
+	}
+
+	want := []FunctionMetrics{
+		{Name: "Start", Receiver: "*Server", StartLine: 5, EndLine: 9, TotalStmts: 2, CoveredStmts: 1, CoveragePct: 50.0},
+		{Name: "helper", StartLine: 11, EndLine: 16, TotalStmts: 3, CoveredStmts: 2, CoveragePct: 66.67},
+	}
+	for i, w := range want {
+		if functions[i] != w {
+			t.Errorf("Expected function %d to be %+v, got %+v", i, w, functions[i])
+		}
+	}
+}
+
+func TestAnalyzeFunctionsParseError(t *testing.T) {
+	_, err := analyzeFunctions("main.go", []byte("package main\nfunc {"), nil)
+	if err == nil {
+		t.Fatal("Expected error due to invalid source, got nil")
+	}
+}
+
+func TestQualifiedName(t *testing.T) {
+	tests := []struct {
+		fn   FunctionMetrics
+		want string
+	}{
+		{FunctionMetrics{Name: "helper"}, "helper"},
+		{FunctionMetrics{Name: "Start", Receiver: "*Server"}, "(*Server).Start"},
+		{FunctionMetrics{Name: "Stop", Receiver: "Server"}, "(Server).Stop"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			if got := tt.fn.QualifiedName(); got != tt.want {
+				t.Errorf("Expected %s, got %s", tt.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,150 @@
+/*
+Copyright (c) Tobias Schäfer. All rights reserved.
+Licensed under the MIT license, see LICENSE in the project root for details.
+*/
+package coverage
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"math"
+	"strings"
+
+	"golang.org/x/tools/cover"
+)
+
+// Directives excluding source from coverage
+const (
+	// IgnoreDirective excludes the function, block or statement starting
+	// on the line of the comment or, for a comment on its own line, on the
+	// line below
+	IgnoreDirective = "coverage:ignore"
+	// IgnoreFileDirective excludes the whole file
+	IgnoreFileDirective = "coverage:ignore-file"
+)
+
+// ignoreRange is a source range excluded by a directive
+type ignoreRange struct {
+	startLine int
+	startCol  int
+	endLine   int
+	endCol    int
+}
+
+// contains reports whether a position lies within the range
+func (r ignoreRange) contains(line, col int) bool {
+	if line < r.startLine || line == r.startLine && col < r.startCol {
+		return false
+	}
+
+	return line < r.endLine || line == r.endLine && col <= r.endCol
+}
+
+// findIgnored parses the source and returns the ranges excluded by ignore
+// directives
+func findIgnored(fileName string, source []byte) ([]ignoreRange, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, fileName, source, parser.ParseComments|parser.SkipObjectResolution)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse source file %s: %w", fileName, err)
+	}
+
+	lines := strings.Split(string(source), "\n")
+
+	var ranges []ignoreRange
+	for _, group := range file.Comments {
+		for _, c := range group.List {
+			switch directive(c.Text) {
+			case IgnoreFileDirective:
+				return []ignoreRange{{1, 1, math.MaxInt, math.MaxInt}}, nil
+			case IgnoreDirective:
+				pos := fset.Position(c.Pos())
+				line := pos.Line
+				if strings.TrimSpace(lines[line-1][:pos.Column-1]) == "" {
+					line = fset.Position(group.End()).Line + 1
+				}
+				if node := outermostNode(file, fset, line); node != nil {
+					start := fset.Position(node.Pos())
+					end := fset.Position(node.End())
+					ranges = append(ranges, ignoreRange{start.Line, start.Column, end.Line, end.Column})
+				}
+			}
+		}
+	}
+
+	return ranges, nil
+}
+
+// directive returns the coverage directive of a comment, if any. The
+// directive may be followed by a reason, e.g. "// coverage:ignore unreachable".
+func directive(text string) string {
+	text = strings.TrimPrefix(text, "//")
+	text = strings.TrimPrefix(text, "/*")
+	text = strings.TrimSuffix(text, "*/")
+
+	fields := strings.Fields(text)
+	if len(fields) == 0 {
+		return ""
+	}
+
+	switch fields[0] {
+	case IgnoreDirective, IgnoreFileDirective:
+		return fields[0]
+	}
+
+	return ""
+}
+
+// outermostNode returns the outermost function declaration or statement
+// starting on the line
+func outermostNode(file *ast.File, fset *token.FileSet, line int) ast.Node {
+	var found ast.Node
+	ast.Inspect(file, func(n ast.Node) bool {
+		if found != nil || n == nil {
+			return false
+		}
+
+		switch n.(type) {
+		case *ast.FuncDecl, ast.Stmt:
+			if fset.Position(n.Pos()).Line == line {
+				found = n
+				return false
+			}
+		}
+
+		start := fset.Position(n.Pos()).Line
+		end := fset.Position(n.End()).Line
+		return start <= line && line <= end
+	})
+
+	return found
+}
+
+// withoutIgnored returns the blocks not starting within an ignored range
+func withoutIgnored(blocks []cover.ProfileBlock, ranges []ignoreRange) []cover.ProfileBlock {
+	if len(ranges) == 0 {
+		return blocks
+	}
+
+	kept := make([]cover.ProfileBlock, 0, len(blocks))
+	for _, b := range blocks {
+		if !isIgnored(b, ranges) {
+			kept = append(kept, b)
+		}
+	}
+
+	return kept
+}
+
+// isIgnored reports whether a block starts within one of the ranges
+func isIgnored(b cover.ProfileBlock, ranges []ignoreRange) bool {
+	for _, r := range ranges {
+		if r.contains(b.StartLine, b.StartCol) {
+			return true
+		}
+	}
+
+	return false
+}
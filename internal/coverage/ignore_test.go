@@ -0,0 +1,129 @@
+/*
+Copyright (c) Tobias Schäfer. All rights reserved.
+Licensed under the MIT license, see LICENSE in the project root for details.
+*/
+package coverage
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"golang.org/x/tools/cover"
+
+	"github.com/tschaefer/cover-ui/internal/source"
+)
+
+const ignoreSource = `package main
+
+import "os"
+
+func run(x int) int {
+	if x < 0 { // coverage:ignore impossible
+		panic("negative")
+	}
+	return x
+}
+
+// coverage:ignore
+func fail() {
+	os.Exit(1)
+}
+
+func pick(x int) int {
+	switch x {
+	case 1:
+		return 1
+	// coverage:ignore
+	default:
+		return 0
+	}
+}
+`
+
+var ignoreBlocks = []cover.ProfileBlock{
+	{StartLine: 5, StartCol: 21, EndLine: 6, EndCol: 11, NumStmt: 1, Count: 1},
+	{StartLine: 6, StartCol: 11, EndLine: 8, EndCol: 3, NumStmt: 1, Count: 0},
+	{StartLine: 9, StartCol: 2, EndLine: 9, EndCol: 10, NumStmt: 1, Count: 1},
+	{StartLine: 13, StartCol: 13, EndLine: 14, EndCol: 12, NumStmt: 1, Count: 0},
+	{StartLine: 17, StartCol: 22, EndLine: 18, EndCol: 11, NumStmt: 1, Count: 1},
+	{StartLine: 19, StartCol: 9, EndLine: 20, EndCol: 11, NumStmt: 1, Count: 1},
+	{StartLine: 22, StartCol: 10, EndLine: 23, EndCol: 11, NumStmt: 1, Count: 0},
+}
+
+func analyzeSource(t *testing.T, content string, blocks []cover.ProfileBlock) *FileMetrics {
+	tmpDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(tmpDir, "main.go"), []byte(content), 0o644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	profile := &cover.Profile{FileName: "example.com/mod/main.go", Mode: "set", Blocks: blocks}
+	metrics, err := Analyze(profile, testModules, source.New(tmpDir))
+	if err != nil {
+		t.Fatalf("Analyze failed: %v", err)
+	}
+
+	return metrics
+}
+
+func TestAnalyzeIgnoreDirectives(t *testing.T) {
+	metrics := analyzeSource(t, ignoreSource, ignoreBlocks)
+
+	if metrics.TotalStmts != 4 || metrics.CoveredStmts != 4 || metrics.CoveragePct != 100 {
+		t.Errorf("Expected 4 of 4 statements covered, got %d of %d (%.2f%%)", metrics.CoveredStmts, metrics.TotalStmts, metrics.CoveragePct)
+	}
+	if metrics.MissedLines != 0 {
+		t.Errorf("Expected no missed lines, got %d", metrics.MissedLines)
+	}
+
+	want := []int{7, 8, 13, 14, 15, 22, 23}
+	if !reflect.DeepEqual(metrics.IgnoredLines, want) {
+		t.Errorf("Expected ignored lines %v, got %v", want, metrics.IgnoredLines)
+	}
+	if metrics.PerLineStatus[6] != int(Covered) {
+		t.Errorf("Expected header of ignored if statement to stay covered, got %d", metrics.PerLineStatus[6])
+	}
+
+	for _, fn := range metrics.Functions {
+		if fn.Name == "fail" && fn.TotalStmts != 0 {
+			t.Errorf("Expected no statements in ignored function, got %d", fn.TotalStmts)
+		}
+	}
+}
+
+func TestAnalyzeIgnoreFile(t *testing.T) {
+	content := "// coverage:ignore-file\n" + ignoreSource
+	blocks := make([]cover.ProfileBlock, len(ignoreBlocks))
+	for i, b := range ignoreBlocks {
+		b.StartLine++
+		b.EndLine++
+		blocks[i] = b
+	}
+
+	metrics := analyzeSource(t, content, blocks)
+
+	if metrics.TotalStmts != 0 || metrics.TrackedLines != 0 {
+		t.Errorf("Expected no statements and tracked lines, got %d and %d", metrics.TotalStmts, metrics.TrackedLines)
+	}
+	if len(metrics.IgnoredLines) != 26 {
+		t.Errorf("Expected all 26 lines to be ignored, got %d", len(metrics.IgnoredLines))
+	}
+}
+
+func TestDirective(t *testing.T) {
+	tests := map[string]string{
+		"// coverage:ignore":                 IgnoreDirective,
+		"//coverage:ignore unreachable":      IgnoreDirective,
+		"/* coverage:ignore */":              IgnoreDirective,
+		"// coverage:ignore-file":            IgnoreFileDirective,
+		"// coverage:ignored":                "",
+		"// see coverage:ignore for details": "",
+	}
+
+	for text, want := range tests {
+		if got := directive(text); got != want {
+			t.Errorf("Expected directive of %q to be %q, got %q", text, want, got)
+		}
+	}
+}
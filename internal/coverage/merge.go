@@ -0,0 +1,103 @@
+/*
+Copyright (c) Tobias Schäfer. All rights reserved.
+Licensed under the MIT license, see LICENSE in the project root for details.
+*/
+package coverage
+
+import (
+	"fmt"
+	"sort"
+
+	"golang.org/x/tools/cover"
+)
+
+// blockKey identifies a profile block by its source position
+type blockKey struct {
+	startLine, startCol int
+	endLine, endCol     int
+}
+
+// Merge combines several parsed coverage profiles into one profile per
+// source file. Blocks covering the same source range are folded into a
+// single block: in set mode a block is covered if any profile covered it,
+// in count and atomic mode the counts are summed up. Count and atomic
+// profiles can be merged with each other, the result is then atomic.
+func Merge(profiles ...[]*cover.Profile) ([]*cover.Profile, error) {
+	mode := ""
+	merged := make(map[string]*cover.Profile)
+	blocks := make(map[string]map[blockKey]int)
+
+	for _, set := range profiles {
+		for _, p := range set {
+			if mode == "" {
+				mode = p.Mode
+			}
+			if p.Mode != mode && (p.Mode == "set" || mode == "set") {
+				return nil, fmt.Errorf("failed to merge profiles: mixed modes %s and %s", mode, p.Mode)
+			}
+			if p.Mode == "atomic" {
+				mode = p.Mode
+			}
+
+			m, ok := merged[p.FileName]
+			if !ok {
+				m = &cover.Profile{FileName: p.FileName, Mode: p.Mode}
+				merged[p.FileName] = m
+				blocks[p.FileName] = make(map[blockKey]int)
+			}
+
+			for _, b := range p.Blocks {
+				if err := mergeBlock(m, blocks[p.FileName], b); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+
+	result := make([]*cover.Profile, 0, len(merged))
+	for _, p := range merged {
+		p.Mode = mode
+		sort.Slice(p.Blocks, func(i, j int) bool {
+			bi, bj := p.Blocks[i], p.Blocks[j]
+			return bi.StartLine < bj.StartLine || bi.StartLine == bj.StartLine && bi.StartCol < bj.StartCol
+		})
+		result = append(result, p)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].FileName < result[j].FileName
+	})
+
+	return result, nil
+}
+
+// mergeBlock adds a block to the merged profile or folds it into an
+// already known block at the same position
+func mergeBlock(p *cover.Profile, index map[blockKey]int, b cover.ProfileBlock) error {
+	key := blockKey{b.StartLine, b.StartCol, b.EndLine, b.EndCol}
+
+	idx, ok := index[key]
+	if !ok {
+		index[key] = len(p.Blocks)
+		p.Blocks = append(p.Blocks, b)
+		return nil
+	}
+
+	existing := &p.Blocks[idx]
+	if existing.NumStmt != b.NumStmt {
+		return fmt.Errorf(
+			"failed to merge profiles: %s:%d.%d,%d.%d has inconsistent statement counts %d and %d",
+			p.FileName, b.StartLine, b.StartCol, b.EndLine, b.EndCol, existing.NumStmt, b.NumStmt,
+		)
+	}
+
+	if p.Mode == "set" {
+		if b.Count > 0 {
+			existing.Count = 1
+		}
+		return nil
+	}
+
+	existing.Count += b.Count
+	return nil
+}
@@ -0,0 +1,101 @@
+/*
+Copyright (c) Tobias Schäfer. All rights reserved.
+Licensed under the MIT license, see LICENSE in the project root for details.
+*/
+package coverage
+
+import (
+	"testing"
+
+	"golang.org/x/tools/cover"
+)
+
+func profileSet(mode string, counts ...int) []*cover.Profile {
+	p := &cover.Profile{FileName: "github.com/test/repo/main.go", Mode: mode}
+	for i, count := range counts {
+		p.Blocks = append(p.Blocks, cover.ProfileBlock{
+			StartLine: i + 1, StartCol: 1, EndLine: i + 1, EndCol: 10, NumStmt: 1, Count: count,
+		})
+	}
+	return []*cover.Profile{p}
+}
+
+func TestMergeSet(t *testing.T) {
+	merged, err := Merge(profileSet("set", 1, 0, 0), profileSet("set", 1, 1, 0))
+	if err != nil {
+		t.Fatalf("Merge failed: %v", err)
+	}
+
+	if len(merged) != 1 {
+		t.Fatalf("Expected 1 profile, got %d", len(merged))
+	}
+
+	want := []int{1, 1, 0}
+	for i, b := range merged[0].Blocks {
+		if b.Count != want[i] {
+			t.Errorf("Expected block %d count %d, got %d", i, want[i], b.Count)
+		}
+	}
+}
+
+func TestMergeCount(t *testing.T) {
+	merged, err := Merge(profileSet("count", 3, 0), profileSet("count", 2, 5))
+	if err != nil {
+		t.Fatalf("Merge failed: %v", err)
+	}
+
+	want := []int{5, 5}
+	for i, b := range merged[0].Blocks {
+		if b.Count != want[i] {
+			t.Errorf("Expected block %d count %d, got %d", i, want[i], b.Count)
+		}
+	}
+}
+
+func TestMergeDistinctFiles(t *testing.T) {
+	other := profileSet("set", 1)
+	other[0].FileName = "github.com/test/repo/aaa.go"
+
+	merged, err := Merge(profileSet("set", 1), other)
+	if err != nil {
+		t.Fatalf("Merge failed: %v", err)
+	}
+
+	if len(merged) != 2 {
+		t.Fatalf("Expected 2 profiles, got %d", len(merged))
+	}
+	if merged[0].FileName != "github.com/test/repo/aaa.go" {
+		t.Errorf("Expected profiles sorted by file name, got %s first", merged[0].FileName)
+	}
+}
+
+func TestMergeModeMismatch(t *testing.T) {
+	_, err := Merge(profileSet("set", 1), profileSet("count", 1))
+	if err == nil {
+		t.Fatal("Expected error due to mode mismatch, got nil")
+	}
+}
+
+func TestMergeCountAtomic(t *testing.T) {
+	merged, err := Merge(profileSet("count", 2), profileSet("atomic", 3))
+	if err != nil {
+		t.Fatalf("Merge failed: %v", err)
+	}
+
+	if merged[0].Mode != "atomic" {
+		t.Errorf("Expected mode atomic, got %s", merged[0].Mode)
+	}
+	if merged[0].Blocks[0].Count != 5 {
+		t.Errorf("Expected summed count 5, got %d", merged[0].Blocks[0].Count)
+	}
+}
+
+func TestMergeStatementMismatch(t *testing.T) {
+	other := profileSet("set", 1)
+	other[0].Blocks[0].NumStmt = 2
+
+	_, err := Merge(profileSet("set", 1), other)
+	if err == nil {
+		t.Fatal("Expected error due to statement count mismatch, got nil")
+	}
+}
@@ -0,0 +1,51 @@
+/*
+Copyright (c) Tobias Schäfer. All rights reserved.
+Licensed under the MIT license, see LICENSE in the project root for details.
+*/
+package coverage
+
+import (
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Package holds the files directly within a single directory, not
+// including subdirectories
+type Package struct {
+	// Dir is the slash separated directory relative to the source root,
+	// "." for the root itself
+	Dir   string
+	Files []*FileMetrics
+}
+
+// Packages groups the files by directory, sorted by directory
+func Packages(files []*FileMetrics) []Package {
+	byDir := make(map[string][]*FileMetrics)
+	for _, f := range files {
+		dir := path.Dir(filepath.ToSlash(f.LocalPath))
+		byDir[dir] = append(byDir[dir], f)
+	}
+
+	pkgs := make([]Package, 0, len(byDir))
+	for dir, members := range byDir {
+		pkgs = append(pkgs, Package{Dir: dir, Files: members})
+	}
+
+	sort.Slice(pkgs, func(i, j int) bool {
+		return pkgs[i].Dir < pkgs[j].Dir
+	})
+
+	return pkgs
+}
+
+// ImportPath returns the import path of a directory within the module
+func ImportPath(module, dir string) string {
+	dir = strings.Trim(filepath.ToSlash(dir), "/")
+	if dir == "" || dir == "." {
+		return module
+	}
+
+	return module + "/" + dir
+}
@@ -0,0 +1,49 @@
+/*
+Copyright (c) Tobias Schäfer. All rights reserved.
+Licensed under the MIT license, see LICENSE in the project root for details.
+*/
+package coverage
+
+import "testing"
+
+func TestPackages(t *testing.T) {
+	files := []*FileMetrics{
+		{LocalPath: "pkg/b.go"},
+		{LocalPath: "main.go"},
+		{LocalPath: "pkg/a.go"},
+		{LocalPath: "pkg/sub/c.go"},
+	}
+
+	pkgs := Packages(files)
+	want := []struct {
+		dir   string
+		files int
+	}{{".", 1}, {"pkg", 2}, {"pkg/sub", 1}}
+
+	if len(pkgs) != len(want) {
+		t.Fatalf("Expected %d packages, got %d", len(want), len(pkgs))
+	}
+	for i, w := range want {
+		if pkgs[i].Dir != w.dir || len(pkgs[i].Files) != w.files {
+			t.Errorf("Expected package %s with %d files, got %s with %d", w.dir, w.files, pkgs[i].Dir, len(pkgs[i].Files))
+		}
+	}
+}
+
+func TestImportPath(t *testing.T) {
+	tests := []struct {
+		dir  string
+		want string
+	}{
+		{"", "example.com/mod"},
+		{".", "example.com/mod"},
+		{"pkg/sub", "example.com/mod/pkg/sub"},
+		{"/pkg/", "example.com/mod/pkg"},
+	}
+
+	for _, tt := range tests {
+		if got := ImportPath("example.com/mod", tt.dir); got != tt.want {
+			t.Errorf("Expected import path %s for %q, got %s", tt.want, tt.dir, got)
+		}
+	}
+}
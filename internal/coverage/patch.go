@@ -0,0 +1,70 @@
+/*
+Copyright (c) Tobias Schäfer. All rights reserved.
+Licensed under the MIT license, see LICENSE in the project root for details.
+*/
+package coverage
+
+// PatchMetrics holds coverage metrics for the changed lines of a patch
+type PatchMetrics struct {
+	Base         string  `json:"base"`
+	TotalFiles   int     `json:"totalFiles"`
+	ChangedLines int     `json:"changedLines"`
+	TrackedLines int     `json:"trackedLines"`
+	CoveredLines int     `json:"coveredLines"`
+	PartialLines int     `json:"partialLines"`
+	MissedLines  int     `json:"missedLines"`
+	CoveragePct  float64 `json:"coveragePct"`
+}
+
+// Patch marks the changed lines of each file and returns the patch
+// coverage. Changes are keyed by local path. The patch coverage is the
+// share of covered lines among the changed lines that hold statements,
+// partial lines count as not covered.
+func Patch(files []*FileMetrics, changes map[string][]int, base string) *PatchMetrics {
+	patch := &PatchMetrics{Base: base}
+
+	for _, f := range files {
+		lines, ok := changes[f.LocalPath]
+		if !ok || len(lines) == 0 {
+			continue
+		}
+
+		f.ChangedLines = lines
+
+		patch.TotalFiles++
+		patch.ChangedLines += len(lines)
+		for _, ln := range lines {
+			if ln < 1 || ln >= len(f.PerLineStatus) || f.PerLineStatus[ln] < 0 {
+				continue
+			}
+
+			patch.TrackedLines++
+			switch LineStatus(f.PerLineStatus[ln]) {
+			case Covered:
+				patch.CoveredLines++
+			case Partial:
+				patch.PartialLines++
+			case Missed:
+				patch.MissedLines++
+			}
+		}
+	}
+
+	if patch.TrackedLines > 0 {
+		patch.CoveragePct = round((float64(patch.CoveredLines)/float64(patch.TrackedLines))*100.0, 2)
+	}
+
+	return patch
+}
+
+// Touched returns the files with changed lines marked by Patch
+func Touched(files []*FileMetrics) []*FileMetrics {
+	var touched []*FileMetrics
+	for _, f := range files {
+		if len(f.ChangedLines) > 0 {
+			touched = append(touched, f)
+		}
+	}
+
+	return touched
+}
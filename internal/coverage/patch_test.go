@@ -0,0 +1,51 @@
+/*
+Copyright (c) Tobias Schäfer. All rights reserved.
+Licensed under the MIT license, see LICENSE in the project root for details.
+*/
+package coverage
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestPatch(t *testing.T) {
+	files := []*FileMetrics{
+		{
+			LocalPath:     "main.go",
+			PerLineStatus: []int{-1, -1, int(Covered), int(Partial), int(Missed), -1},
+		},
+		{
+			LocalPath:     "untouched.go",
+			PerLineStatus: []int{-1, int(Missed)},
+		},
+	}
+	changes := map[string][]int{
+		"main.go":  {1, 2, 3, 4},
+		"other.go": {1},
+	}
+
+	patch := Patch(files, changes, "main")
+	touched := Touched(files)
+
+	if len(touched) != 1 || touched[0].LocalPath != "main.go" {
+		t.Fatalf("Expected only main.go to be touched, got %v", touched)
+	}
+	if !reflect.DeepEqual(touched[0].ChangedLines, []int{1, 2, 3, 4}) {
+		t.Errorf("Expected changed lines to be set, got %v", touched[0].ChangedLines)
+	}
+
+	want := &PatchMetrics{
+		Base:         "main",
+		TotalFiles:   1,
+		ChangedLines: 4,
+		TrackedLines: 3,
+		CoveredLines: 1,
+		PartialLines: 1,
+		MissedLines:  1,
+		CoveragePct:  33.33,
+	}
+	if !reflect.DeepEqual(patch, want) {
+		t.Errorf("Expected patch metrics %+v, got %+v", want, patch)
+	}
+}
@@ -0,0 +1,57 @@
+/*
+Copyright (c) Tobias Schäfer. All rights reserved.
+Licensed under the MIT license, see LICENSE in the project root for details.
+*/
+package coverage
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"path"
+	"slices"
+	"sort"
+
+	"golang.org/x/tools/cover"
+)
+
+// Replace returns the base profiles with all profiles of the given packages,
+// identified by import path, replaced by the fresh profiles. Unlike Merge
+// the fresh results supersede the previous ones, files of the packages not
+// part of the fresh profiles are dropped.
+func Replace(base, fresh []*cover.Profile, packages []string) []*cover.Profile {
+	result := make([]*cover.Profile, 0, len(base)+len(fresh))
+	for _, p := range base {
+		if !slices.Contains(packages, path.Dir(p.FileName)) {
+			result = append(result, p)
+		}
+	}
+	result = append(result, fresh...)
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].FileName < result[j].FileName
+	})
+
+	return result
+}
+
+// WriteProfile writes the profiles in the text format of go test
+// -coverprofile
+func WriteProfile(w io.Writer, profiles []*cover.Profile) error {
+	bw := bufio.NewWriter(w)
+
+	mode := "set"
+	if len(profiles) > 0 {
+		mode = profiles[0].Mode
+	}
+	_, _ = fmt.Fprintf(bw, "mode: %s\n", mode)
+
+	for _, p := range profiles {
+		for _, b := range p.Blocks {
+			_, _ = fmt.Fprintf(bw, "%s:%d.%d,%d.%d %d %d\n",
+				p.FileName, b.StartLine, b.StartCol, b.EndLine, b.EndCol, b.NumStmt, b.Count)
+		}
+	}
+
+	return bw.Flush()
+}
@@ -0,0 +1,70 @@
+/*
+Copyright (c) Tobias Schäfer. All rights reserved.
+Licensed under the MIT license, see LICENSE in the project root for details.
+*/
+package coverage
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"golang.org/x/tools/cover"
+)
+
+func TestReplace(t *testing.T) {
+	base := []*cover.Profile{
+		{FileName: "github.com/test/repo/main.go", Mode: "set"},
+		{FileName: "github.com/test/repo/pkg/a.go", Mode: "set"},
+		{FileName: "github.com/test/repo/pkg/removed.go", Mode: "set"},
+		{FileName: "github.com/test/repo/pkg/sub/b.go", Mode: "set"},
+	}
+	fresh := []*cover.Profile{
+		{FileName: "github.com/test/repo/pkg/a.go", Mode: "set"},
+		{FileName: "github.com/test/repo/pkg/new.go", Mode: "set"},
+	}
+
+	result := Replace(base, fresh, []string{"github.com/test/repo/pkg"})
+
+	var names []string
+	for _, p := range result {
+		names = append(names, p.FileName)
+	}
+	want := []string{
+		"github.com/test/repo/main.go",
+		"github.com/test/repo/pkg/a.go",
+		"github.com/test/repo/pkg/new.go",
+		"github.com/test/repo/pkg/sub/b.go",
+	}
+	if !reflect.DeepEqual(names, want) {
+		t.Errorf("Expected profiles %v, got %v", want, names)
+	}
+
+	if result[1] != fresh[0] {
+		t.Errorf("Expected fresh profile of pkg/a.go, got base profile")
+	}
+}
+
+func TestWriteProfile(t *testing.T) {
+	profiles := profileSet("count", 3, 0)
+
+	var buf bytes.Buffer
+	if err := WriteProfile(&buf, profiles); err != nil {
+		t.Fatalf("WriteProfile failed: %v", err)
+	}
+
+	want := "mode: count\n" +
+		"github.com/test/repo/main.go:1.1,1.10 1 3\n" +
+		"github.com/test/repo/main.go:2.1,2.10 1 0\n"
+	if buf.String() != want {
+		t.Errorf("Expected profile %q, got %q", want, buf.String())
+	}
+
+	parsed, err := cover.ParseProfilesFromReader(&buf)
+	if err != nil {
+		t.Fatalf("Failed to parse written profile: %v", err)
+	}
+	if !reflect.DeepEqual(parsed, profiles) {
+		t.Errorf("Expected parsed profile %+v, got %+v", profiles[0], parsed[0])
+	}
+}
@@ -0,0 +1,186 @@
+/*
+Copyright (c) Tobias Schäfer. All rights reserved.
+Licensed under the MIT license, see LICENSE in the project root for details.
+*/
+package delta
+
+import (
+	"math"
+	"path/filepath"
+	"sort"
+
+	"github.com/tschaefer/cover-ui/internal/coverage"
+	"github.com/tschaefer/cover-ui/internal/tree"
+)
+
+// Entry is a directory or file of a comparison. Base or Head is nil for
+// entries existing on one side only.
+type Entry struct {
+	Name  string
+	Path  string
+	IsDir bool
+	Depth int
+	Base  *tree.Node
+	Head  *tree.Node
+	// NewlyCovered and NewlyUncovered count the lines that changed their
+	// status, summed up for directories
+	NewlyCovered   int
+	NewlyUncovered int
+}
+
+// Delta returns the change of statement coverage in percentage points, 0
+// for entries existing on one side only
+func (e Entry) Delta() float64 {
+	if e.Base == nil || e.Head == nil {
+		return 0
+	}
+
+	return round(e.Head.CoveragePct-e.Base.CoveragePct, 2)
+}
+
+// Comparison holds the coverage trees of two reports and their entries in
+// depth-first order, directories first
+type Comparison struct {
+	Base    *tree.Node
+	Head    *tree.Node
+	Entries []Entry
+}
+
+// Delta returns the change of the total statement coverage
+func (c *Comparison) Delta() float64 {
+	return round(c.Head.CoveragePct-c.Base.CoveragePct, 2)
+}
+
+// Compare builds the comparison of two sets of file metrics matched by
+// their local path. Lines tracked on both sides that became covered or
+// uncovered are recorded in the head file metrics.
+func Compare(base, head []*coverage.FileMetrics) *Comparison {
+	baseFiles := make(map[string]*coverage.FileMetrics, len(base))
+	for _, f := range base {
+		baseFiles[f.LocalPath] = f
+	}
+	for _, f := range head {
+		if b, ok := baseFiles[f.LocalPath]; ok {
+			f.NewlyCoveredLines, f.NewlyUncoveredLines = changedLines(b, f)
+		}
+	}
+
+	c := &Comparison{Base: tree.Build(base), Head: tree.Build(head)}
+	c.walk(c.Base, c.Head, 0)
+
+	return c
+}
+
+// changedLines returns the lines missed in base and executed in head and
+// vice versa
+func changedLines(base, head *coverage.FileMetrics) ([]int, []int) {
+	var covered, uncovered []int
+	for ln := 1; ln < len(head.PerLineStatus) && ln < len(base.PerLineStatus); ln++ {
+		b, h := base.PerLineStatus[ln], head.PerLineStatus[ln]
+		if b < 0 || h < 0 {
+			continue
+		}
+
+		switch {
+		case b == int(coverage.Missed) && h != int(coverage.Missed):
+			covered = append(covered, ln)
+		case b != int(coverage.Missed) && h == int(coverage.Missed):
+			uncovered = append(uncovered, ln)
+		}
+	}
+
+	return covered, uncovered
+}
+
+// walk appends the entries of the children of a directory on both sides
+// and returns the counts of changed lines
+func (c *Comparison) walk(base, head *tree.Node, depth int) (int, int) {
+	covered, uncovered := 0, 0
+	for _, p := range pairChildren(base, head) {
+		node := p.head
+		if node == nil {
+			node = p.base
+		}
+
+		path := filepath.ToSlash(node.Path)
+		if !node.IsDir {
+			path = node.File.LocalPath
+		}
+
+		idx := len(c.Entries)
+		c.Entries = append(c.Entries, Entry{Name: node.Name, Path: path, IsDir: node.IsDir, Depth: depth, Base: p.base, Head: p.head})
+
+		e := &c.Entries[idx]
+		switch {
+		case node.IsDir:
+			newlyCovered, newlyUncovered := c.walk(p.base, p.head, depth+1)
+			e = &c.Entries[idx]
+			e.NewlyCovered, e.NewlyUncovered = newlyCovered, newlyUncovered
+		case p.head != nil:
+			e.NewlyCovered = len(p.head.File.NewlyCoveredLines)
+			e.NewlyUncovered = len(p.head.File.NewlyUncoveredLines)
+		}
+
+		covered += e.NewlyCovered
+		uncovered += e.NewlyUncovered
+	}
+
+	return covered, uncovered
+}
+
+// pair holds the nodes of a directory or file on both sides
+type pair struct {
+	base *tree.Node
+	head *tree.Node
+}
+
+// pairChildren matches the children of two directories by name and kind,
+// sorted directories first, then alphabetically
+func pairChildren(base, head *tree.Node) []*pair {
+	type key struct {
+		name  string
+		isDir bool
+	}
+
+	index := make(map[key]*pair)
+	var keys []key
+	for side, parent := range []*tree.Node{base, head} {
+		if parent == nil {
+			continue
+		}
+		for _, child := range parent.Children {
+			k := key{child.Name, child.IsDir}
+			p, ok := index[k]
+			if !ok {
+				p = &pair{}
+				index[k] = p
+				keys = append(keys, k)
+			}
+			if side == 0 {
+				p.base = child
+			} else {
+				p.head = child
+			}
+		}
+	}
+
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].isDir != keys[j].isDir {
+			return keys[i].isDir
+		}
+		return keys[i].name < keys[j].name
+	})
+
+	pairs := make([]*pair, 0, len(keys))
+	for _, k := range keys {
+		pairs = append(pairs, index[k])
+	}
+
+	return pairs
+}
+
+// round rounds a float to specified precision
+func round(v float64, prec int) float64 {
+	p := math.Pow10(prec)
+	return math.Round(v*p) / p
+}
@@ -0,0 +1,81 @@
+/*
+Copyright (c) Tobias Schäfer. All rights reserved.
+Licensed under the MIT license, see LICENSE in the project root for details.
+*/
+package delta
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/tschaefer/cover-ui/internal/coverage"
+)
+
+const (
+	missed  = int(coverage.Missed)
+	partial = int(coverage.Partial)
+	covered = int(coverage.Covered)
+)
+
+func file(localPath string, total, covered int, statuses ...int) *coverage.FileMetrics {
+	return &coverage.FileMetrics{
+		FileName:      "example.com/mod/" + localPath,
+		LocalPath:     localPath,
+		TotalStmts:    total,
+		CoveredStmts:  covered,
+		CoveragePct:   float64(covered) / float64(total) * 100,
+		PerLineStatus: append([]int{-1}, statuses...),
+	}
+}
+
+func TestCompare(t *testing.T) {
+	base := []*coverage.FileMetrics{
+		file("main.go", 4, 2, covered, missed, missed, -1),
+		file("pkg/a.go", 2, 2, covered, covered),
+		file("pkg/old.go", 1, 0, missed),
+	}
+	head := []*coverage.FileMetrics{
+		file("main.go", 4, 3, covered, partial, missed, covered),
+		file("pkg/a.go", 2, 1, missed, covered),
+		file("pkg/new.go", 1, 1, covered),
+	}
+
+	c := Compare(base, head)
+
+	if c.Base.CoveragePct != 57.14 || c.Head.CoveragePct != 71.43 || c.Delta() != 14.29 {
+		t.Errorf("Unexpected totals %.2f -> %.2f (%.2f)", c.Base.CoveragePct, c.Head.CoveragePct, c.Delta())
+	}
+
+	var paths []string
+	for _, e := range c.Entries {
+		paths = append(paths, e.Path)
+	}
+	want := []string{"pkg", "pkg/a.go", "pkg/new.go", "pkg/old.go", "main.go"}
+	if !reflect.DeepEqual(paths, want) {
+		t.Fatalf("Expected entries %v, got %v", want, paths)
+	}
+
+	if !reflect.DeepEqual(head[0].NewlyCoveredLines, []int{2}) || head[0].NewlyUncoveredLines != nil {
+		t.Errorf("Unexpected changed lines of main.go %v %v", head[0].NewlyCoveredLines, head[0].NewlyUncoveredLines)
+	}
+
+	pkg := c.Entries[0]
+	if !pkg.IsDir || pkg.Depth != 0 || pkg.NewlyCovered != 0 || pkg.NewlyUncovered != 1 {
+		t.Errorf("Unexpected directory entry %+v", pkg)
+	}
+	if pkg.Delta() != 0 || c.Entries[1].Delta() != -50 {
+		t.Errorf("Expected deltas 0 of pkg and -50 of pkg/a.go, got %.2f and %.2f", pkg.Delta(), c.Entries[1].Delta())
+	}
+
+	added, removed := c.Entries[2], c.Entries[3]
+	if added.Base != nil || added.Head == nil || added.Depth != 1 || added.Delta() != 0 {
+		t.Errorf("Unexpected added entry %+v", added)
+	}
+	if removed.Base == nil || removed.Head != nil || removed.NewlyCovered != 0 {
+		t.Errorf("Unexpected removed entry %+v", removed)
+	}
+
+	if main := c.Entries[4]; main.Delta() != 25 || main.NewlyCovered != 1 {
+		t.Errorf("Unexpected main.go entry delta %.2f, newly covered %d", main.Delta(), main.NewlyCovered)
+	}
+}
@@ -0,0 +1,145 @@
+/*
+Copyright (c) Tobias Schäfer. All rights reserved.
+Licensed under the MIT license, see LICENSE in the project root for details.
+*/
+package diff
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// hunkHeader matches the new file range of a unified diff hunk header,
+// e.g. "@@ -10,2 +12,3 @@"
+var hunkHeader = regexp.MustCompile(`^@@ -\d+(?:,\d+)? \+(\d+)(?:,(\d+))? @@`)
+
+// Changes returns the changed line numbers per file between the base
+// revision and the working tree of the git repository at dir. File paths
+// are relative to dir, untracked Go files count as entirely changed.
+func Changes(dir, base string) (map[string][]int, error) {
+	out, err := git(dir, "diff", "--relative", "--no-color", "--no-ext-diff", "--src-prefix=a/", "--dst-prefix=b/", "--unified=0", base, "--")
+	if err != nil {
+		return nil, err
+	}
+
+	changes, err := Parse(bytes.NewReader(out))
+	if err != nil {
+		return nil, err
+	}
+
+	out, err = git(dir, "ls-files", "--others", "--exclude-standard", "--", "*.go")
+	if err != nil {
+		return nil, err
+	}
+
+	for _, path := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+		if path == "" {
+			continue
+		}
+
+		lines, err := countLines(filepath.Join(dir, path))
+		if err != nil {
+			return nil, err
+		}
+		for ln := 1; ln <= lines; ln++ {
+			changes[path] = append(changes[path], ln)
+		}
+	}
+
+	return changes, nil
+}
+
+// Parse reads a unified diff and returns the added or modified line
+// numbers of the new file side per file path
+func Parse(r io.Reader) (map[string][]int, error) {
+	changes := make(map[string][]int)
+	current := ""
+
+	s := bufio.NewScanner(r)
+	s.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for s.Scan() {
+		line := s.Text()
+
+		if path, ok := strings.CutPrefix(line, "+++ "); ok {
+			current = ""
+			if path != "/dev/null" {
+				current = strings.TrimPrefix(path, "b/")
+			}
+			continue
+		}
+
+		m := hunkHeader.FindStringSubmatch(line)
+		if m == nil || current == "" {
+			continue
+		}
+
+		start, err := strconv.Atoi(m[1])
+		if err != nil {
+			return nil, fmt.Errorf("invalid hunk header %q: %w", line, err)
+		}
+		count := 1
+		if m[2] != "" {
+			if count, err = strconv.Atoi(m[2]); err != nil {
+				return nil, fmt.Errorf("invalid hunk header %q: %w", line, err)
+			}
+		}
+
+		for ln := start; ln < start+count; ln++ {
+			changes[current] = append(changes[current], ln)
+		}
+	}
+
+	if err := s.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read diff: %w", err)
+	}
+
+	for _, lines := range changes {
+		sort.Ints(lines)
+	}
+
+	return changes, nil
+}
+
+// Head returns the commit hash of HEAD of the git repository at dir
+func Head(dir string) (string, error) {
+	out, err := git(dir, "rev-parse", "HEAD")
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimSpace(string(out)), nil
+}
+
+// git runs a git command in dir and returns its standard output
+func git(dir string, args ...string) ([]byte, error) {
+	cmd := exec.Command("git", append([]string{"-C", dir}, args...)...)
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to run git %s: %w: %s", args[0], err, strings.TrimSpace(stderr.String()))
+	}
+
+	return out, nil
+}
+
+// countLines returns the number of lines of a file
+func countLines(path string) (int, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read untracked file: %w", err)
+	}
+
+	return len(strings.Split(strings.TrimRight(string(content), "\n"), "\n")), nil
+}
@@ -0,0 +1,147 @@
+/*
+Copyright (c) Tobias Schäfer. All rights reserved.
+Licensed under the MIT license, see LICENSE in the project root for details.
+*/
+package exclude
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// Defaults are the patterns excluded unless configured otherwise: vendored
+// code, protobuf generated code and mocks
+var Defaults = []string{"vendor", "mocks", "*.pb.go", "mock_*.go", "*_mock.go"}
+
+// Reasons for excluding a file
+const (
+	ReasonPattern   = "pattern"
+	ReasonInclude   = "include"
+	ReasonGenerated = "generated"
+	// ReasonIgnored marks files whose statements are all excluded by
+	// ignore directives
+	ReasonIgnored = "ignored"
+)
+
+// File describes a file left out of the report
+type File struct {
+	FileName  string `json:"fileName"`
+	LocalPath string `json:"localPath"`
+	Reason    string `json:"reason"`
+	Pattern   string `json:"pattern,omitempty"`
+}
+
+// generatedHeader is the comment marking generated Go files, see
+// https://go.dev/s/generatedcode
+var generatedHeader = regexp.MustCompile(`^// Code generated .* DO NOT EDIT\.$`)
+
+// Filter decides which files are part of the report. Patterns are matched
+// against the path relative to the source root in slash notation. A pattern
+// without a slash matches the name of the file or of any parent directory,
+// otherwise it matches the whole path where ** spans any number of
+// directories.
+type Filter struct {
+	include []string
+	exclude []string
+}
+
+// New creates a filter, files must match one of the include patterns if any
+// are given and none of the exclude patterns
+func New(include, exclude []string) (*Filter, error) {
+	for _, pattern := range slices.Concat(include, exclude) {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid pattern %q: %w", pattern, err)
+		}
+	}
+
+	return &Filter{include: include, exclude: exclude}, nil
+}
+
+// Match checks the path of a file against the patterns and returns the
+// reason and pattern if the file is excluded
+func (f *Filter) Match(localPath string) (string, string, bool) {
+	if len(f.include) > 0 && matchAny(f.include, localPath) == "" {
+		return ReasonInclude, "", true
+	}
+	if pattern := matchAny(f.exclude, localPath); pattern != "" {
+		return ReasonPattern, pattern, true
+	}
+
+	return "", "", false
+}
+
+// IsGenerated reports whether the source carries the generated code header
+// before the package clause
+func IsGenerated(source []byte) bool {
+	scanner := bufio.NewScanner(bytes.NewReader(source))
+	scanner.Buffer(nil, len(source)+1)
+	for scanner.Scan() {
+		line := strings.TrimSuffix(scanner.Text(), "\r")
+		if generatedHeader.MatchString(line) {
+			return true
+		}
+		if strings.HasPrefix(line, "package ") {
+			return false
+		}
+	}
+
+	return false
+}
+
+// matchAny returns the first pattern matching the path
+func matchAny(patterns []string, localPath string) string {
+	for _, pattern := range patterns {
+		if match(pattern, localPath) {
+			return pattern
+		}
+	}
+
+	return ""
+}
+
+// match reports whether the pattern matches the path
+func match(pattern, localPath string) bool {
+	localPath = strings.TrimPrefix(path.Clean(localPath), "/")
+	segments := strings.Split(localPath, "/")
+
+	if !strings.Contains(pattern, "/") {
+		for _, segment := range segments {
+			if ok, _ := path.Match(pattern, segment); ok {
+				return true
+			}
+		}
+		return false
+	}
+
+	return matchSegments(strings.Split(strings.Trim(pattern, "/"), "/"), segments)
+}
+
+// matchSegments matches path segments against pattern segments, ** matches
+// zero or more segments
+func matchSegments(pattern, segments []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for i := 0; i <= len(segments); i++ {
+				if matchSegments(pattern[1:], segments[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+
+		if len(segments) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(pattern[0], segments[0]); !ok {
+			return false
+		}
+		pattern, segments = pattern[1:], segments[1:]
+	}
+
+	return len(segments) == 0
+}
@@ -0,0 +1,76 @@
+/*
+Copyright (c) Tobias Schäfer. All rights reserved.
+Licensed under the MIT license, see LICENSE in the project root for details.
+*/
+package exclude
+
+import (
+	"testing"
+)
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		pattern   string
+		localPath string
+		want      bool
+	}{
+		{"*.pb.go", "api/v1/service.pb.go", true},
+		{"*.pb.go", "api/v1/service.go", false},
+		{"vendor", "vendor/github.com/pkg/errors/errors.go", true},
+		{"vendor", "svc/vendor/lib/lib.go", true},
+		{"mocks", "internal/mocks/store.go", true},
+		{"mocks", "internal/mocksutil/store.go", false},
+		{"internal/**", "internal/a/b/c.go", true},
+		{"internal/**", "cmd/main.go", false},
+		{"**/testutil/*.go", "pkg/testutil/helpers.go", true},
+		{"**/testutil/*.go", "testutil/helpers.go", true},
+		{"**/testutil/*.go", "pkg/testutil/sub/helpers.go", false},
+		{"cmd/*.go", "cmd/main.go", true},
+		{"cmd/*.go", "cmd/tool/main.go", false},
+	}
+
+	for _, tt := range tests {
+		if got := match(tt.pattern, tt.localPath); got != tt.want {
+			t.Errorf("Expected match(%q, %q) to be %v, got %v", tt.pattern, tt.localPath, tt.want, got)
+		}
+	}
+}
+
+func TestFilter(t *testing.T) {
+	if _, err := New(nil, []string{"[a-"}); err == nil {
+		t.Errorf("Expected error for invalid pattern, got nil")
+	}
+
+	f, err := New([]string{"internal/**"}, Defaults)
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+
+	if reason, _, excluded := f.Match("cmd/main.go"); !excluded || reason != ReasonInclude {
+		t.Errorf("Expected cmd/main.go to be excluded by include patterns, got %v (%s)", excluded, reason)
+	}
+	if reason, pattern, excluded := f.Match("internal/api/api.pb.go"); !excluded || reason != ReasonPattern || pattern != "*.pb.go" {
+		t.Errorf("Expected api.pb.go to be excluded by pattern *.pb.go, got %v (%s %s)", excluded, reason, pattern)
+	}
+	if _, _, excluded := f.Match("internal/api/api.go"); excluded {
+		t.Errorf("Expected api.go to be included")
+	}
+}
+
+func TestIsGenerated(t *testing.T) {
+	tests := []struct {
+		source string
+		want   bool
+	}{
+		{"// Code generated by protoc-gen-go. DO NOT EDIT.\n\npackage api\n", true},
+		{"// Copyright\n\n// Code generated by mockgen. DO NOT EDIT.\r\npackage mocks\n", true},
+		{"package api\n\n// Code generated by hand. DO NOT EDIT.\n", false},
+		{"// Code generated by hand, do not edit.\npackage api\n", false},
+	}
+
+	for _, tt := range tests {
+		if got := IsGenerated([]byte(tt.source)); got != tt.want {
+			t.Errorf("Expected IsGenerated(%q) to be %v, got %v", tt.source, tt.want, got)
+		}
+	}
+}
@@ -0,0 +1,154 @@
+/*
+Copyright (c) Tobias Schäfer. All rights reserved.
+Licensed under the MIT license, see LICENSE in the project root for details.
+*/
+package badge
+
+import (
+	"fmt"
+	"html"
+	"math"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/tschaefer/cover-ui/internal/coverage"
+	"github.com/tschaefer/cover-ui/internal/generator/color"
+)
+
+// FileName is the name of the total coverage badge within the output
+// directory
+const FileName = "badge.svg"
+
+// Options configure the badges
+type Options struct {
+	// Label is the text of the left badge half
+	Label string
+	// Red and Green are the coverage percentages at and below which the
+	// badge is red and at and above which it is green, the color band
+	// passes yellow halfway in between
+	Red   float64
+	Green float64
+	// Packages writes a badge per package directory below badges/
+	Packages bool
+}
+
+// DefaultOptions returns options with the color band of the index page
+func DefaultOptions() Options {
+	return Options{Label: "coverage", Red: 0, Green: 100}
+}
+
+// Validate checks the color band thresholds
+func (o Options) Validate() error {
+	if o.Red < 0 || o.Green > 100 || o.Red >= o.Green {
+		return fmt.Errorf("invalid badge thresholds %g and %g, must satisfy 0 <= red < green <= 100", o.Red, o.Green)
+	}
+
+	return nil
+}
+
+// Generate writes the total coverage badge and optionally a badge per
+// package directory, e.g. badges/internal/server/badge.svg
+func Generate(files []*coverage.FileMetrics, outDir string, opts Options) error {
+	if err := os.MkdirAll(outDir, 0o755); err != nil {
+		return fmt.Errorf("failed to create output directory: %w", err)
+	}
+
+	if err := write(filepath.Join(outDir, FileName), coverage.Statistics(files).CoveragePct, opts); err != nil {
+		return err
+	}
+
+	if !opts.Packages {
+		return nil
+	}
+
+	for _, pkg := range coverage.Packages(files) {
+		if path.IsAbs(pkg.Dir) || pkg.Dir == ".." || strings.HasPrefix(pkg.Dir, "../") {
+			continue
+		}
+
+		outPath := filepath.Join(outDir, "badges", filepath.FromSlash(pkg.Dir), FileName)
+		if err := os.MkdirAll(filepath.Dir(outPath), 0o755); err != nil {
+			return fmt.Errorf("failed to create badge directory: %w", err)
+		}
+		if err := write(outPath, coverage.Statistics(pkg.Files).CoveragePct, opts); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// write writes a single badge
+func write(outPath string, pct float64, opts Options) error {
+	if err := os.WriteFile(outPath, []byte(SVG(opts.Label, pct, opts)), 0o644); err != nil {
+		return fmt.Errorf("failed to write badge: %w", err)
+	}
+
+	return nil
+}
+
+// SVG renders a flat badge with the label and the coverage percentage
+func SVG(label string, pct float64, opts Options) string {
+	value := fmt.Sprintf("%.1f%%", pct)
+
+	labelWidth := textWidth(label) + 10
+	valueWidth := textWidth(value) + 10
+	width := labelWidth + valueWidth
+	title := html.EscapeString(label + ": " + value)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="20" role="img" aria-label="%s">`, width, title)
+	fmt.Fprintf(&b, `<title>%s</title>`, title)
+	b.WriteString(`<linearGradient id="s" x2="0" y2="100%"><stop offset="0" stop-color="#bbb" stop-opacity=".1"/><stop offset="1" stop-opacity=".1"/></linearGradient>`)
+	fmt.Fprintf(&b, `<clipPath id="r"><rect width="%d" height="20" rx="3" fill="#fff"/></clipPath>`, width)
+	b.WriteString(`<g clip-path="url(#r)">`)
+	fmt.Fprintf(&b, `<rect width="%d" height="20" fill="#555"/>`, labelWidth)
+	fmt.Fprintf(&b, `<rect x="%d" width="%d" height="20" fill="%s"/>`, labelWidth, valueWidth, Color(pct, opts))
+	fmt.Fprintf(&b, `<rect width="%d" height="20" fill="url(#s)"/>`, width)
+	b.WriteString(`</g>`)
+	b.WriteString(`<g fill="#fff" text-anchor="middle" font-family="Verdana,Geneva,DejaVu Sans,sans-serif" font-size="11">`)
+	writeText(&b, labelWidth/2, html.EscapeString(label))
+	writeText(&b, labelWidth+valueWidth/2, value)
+	b.WriteString(`</g></svg>`)
+	b.WriteString("\n")
+
+	return b.String()
+}
+
+// writeText writes a text with a shadow centered at x
+func writeText(b *strings.Builder, x int, text string) {
+	fmt.Fprintf(b, `<text x="%d" y="15" fill="#010101" fill-opacity=".3">%s</text>`, x, text)
+	fmt.Fprintf(b, `<text x="%d" y="14">%s</text>`, x, text)
+}
+
+// Color returns the red/yellow/green band color of a coverage percentage
+// as hex triplet, the band of the index page stretched between the red and
+// green thresholds
+func Color(pct float64, opts Options) string {
+	ratio := (pct - opts.Red) / (opts.Green - opts.Red)
+
+	return color.Coverage(ratio * 100).Hex()
+}
+
+// textWidth approximates the width in pixels of a text set in 11px Verdana
+func textWidth(text string) int {
+	width := 0.0
+	for _, r := range text {
+		switch {
+		case strings.ContainsRune("ijlI.,:;!|' ", r):
+			width += 3.9
+		case strings.ContainsRune("frt()[]-/", r):
+			width += 4.9
+		case strings.ContainsRune("mwMW%", r):
+			width += 10.5
+		case r >= 'A' && r <= 'Z':
+			width += 7.6
+		default:
+			width += 6.9
+		}
+	}
+
+	return int(math.Ceil(width))
+}
@@ -0,0 +1,98 @@
+/*
+Copyright (c) Tobias Schäfer. All rights reserved.
+Licensed under the MIT license, see LICENSE in the project root for details.
+*/
+package badge
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/tschaefer/cover-ui/internal/coverage"
+)
+
+func TestColor(t *testing.T) {
+	tests := []struct {
+		pct  float64
+		opts Options
+		want string
+	}{
+		{0, DefaultOptions(), "#ef4444"},
+		{25, DefaultOptions(), "#ef7128"},
+		{50, DefaultOptions(), "#ef9e0b"},
+		{100, DefaultOptions(), "#22c55e"},
+		{120, DefaultOptions(), "#22c55e"},
+		{40, Options{Red: 40, Green: 80}, "#ef4444"},
+		{60, Options{Red: 40, Green: 80}, "#ef9e0b"},
+		{85, Options{Red: 40, Green: 80}, "#22c55e"},
+	}
+
+	for _, tt := range tests {
+		if got := Color(tt.pct, tt.opts); got != tt.want {
+			t.Errorf("Expected color %s for %.1f%%, got %s", tt.want, tt.pct, got)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	if err := DefaultOptions().Validate(); err != nil {
+		t.Errorf("Expected default options to be valid, got %v", err)
+	}
+
+	for _, opts := range []Options{{Red: 80, Green: 40}, {Red: 50, Green: 50}, {Red: -1, Green: 50}, {Red: 0, Green: 101}} {
+		if err := opts.Validate(); err == nil {
+			t.Errorf("Expected error for thresholds %g and %g", opts.Red, opts.Green)
+		}
+	}
+}
+
+func TestSVG(t *testing.T) {
+	svg := SVG("cov <&>", 87.5, DefaultOptions())
+
+	for _, want := range []string{
+		`aria-label="cov &lt;&amp;&gt;: 87.5%"`,
+		`<title>cov &lt;&amp;&gt;: 87.5%</title>`,
+		`fill="` + Color(87.5, DefaultOptions()) + `"`,
+		`>87.5%</text>`,
+	} {
+		if !strings.Contains(svg, want) {
+			t.Errorf("Expected badge to contain %q, got %s", want, svg)
+		}
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	files := []*coverage.FileMetrics{
+		{LocalPath: "main.go", TotalStmts: 4, CoveredStmts: 4},
+		{LocalPath: "pkg/a.go", TotalStmts: 4, CoveredStmts: 1},
+		{LocalPath: "pkg/b.go", TotalStmts: 2, CoveredStmts: 0},
+		{LocalPath: "/abs/c.go", TotalStmts: 2, CoveredStmts: 0},
+	}
+
+	outDir := t.TempDir()
+	opts := DefaultOptions()
+	opts.Packages = true
+	if err := Generate(files, outDir, opts); err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+
+	for name, want := range map[string]string{
+		FileName:                                 "41.7%",
+		filepath.Join("badges", FileName):        "100.0%",
+		filepath.Join("badges", "pkg", FileName): "16.7%",
+	} {
+		content, err := os.ReadFile(filepath.Join(outDir, name))
+		if err != nil {
+			t.Fatalf("Expected badge %s, got %v", name, err)
+		}
+		if !strings.Contains(string(content), ">"+want+"</text>") {
+			t.Errorf("Expected badge %s to show %s, got %s", name, want, content)
+		}
+	}
+
+	if _, err := os.Stat(filepath.Join(outDir, "badges", "abs")); !os.IsNotExist(err) {
+		t.Errorf("Expected no badge for files outside the source root")
+	}
+}
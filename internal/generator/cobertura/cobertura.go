@@ -0,0 +1,284 @@
+/*
+Copyright (c) Tobias Schäfer. All rights reserved.
+Licensed under the MIT license, see LICENSE in the project root for details.
+*/
+package cobertura
+
+import (
+	"encoding/xml"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/tschaefer/cover-ui/internal/coverage"
+	"github.com/tschaefer/cover-ui/internal/tree"
+	"github.com/tschaefer/cover-ui/internal/version"
+)
+
+const docType = `<!DOCTYPE coverage SYSTEM "http://cobertura.sourceforge.net/xml/coverage-04.dtd">`
+
+// Coverage is the root element of a Cobertura report
+type Coverage struct {
+	XMLName         xml.Name  `xml:"coverage"`
+	LineRate        float64   `xml:"line-rate,attr"`
+	BranchRate      float64   `xml:"branch-rate,attr"`
+	LinesCovered    int       `xml:"lines-covered,attr"`
+	LinesValid      int       `xml:"lines-valid,attr"`
+	BranchesCovered int       `xml:"branches-covered,attr"`
+	BranchesValid   int       `xml:"branches-valid,attr"`
+	Complexity      float64   `xml:"complexity,attr"`
+	Version         string    `xml:"version,attr"`
+	Timestamp       int64     `xml:"timestamp,attr"`
+	Sources         []string  `xml:"sources>source"`
+	Packages        []Package `xml:"packages>package"`
+}
+
+// Package holds the classes of a single directory
+type Package struct {
+	Name       string  `xml:"name,attr"`
+	LineRate   float64 `xml:"line-rate,attr"`
+	BranchRate float64 `xml:"branch-rate,attr"`
+	Complexity float64 `xml:"complexity,attr"`
+	Classes    []Class `xml:"classes>class"`
+}
+
+// Class holds the coverage of a single source file
+type Class struct {
+	Name       string   `xml:"name,attr"`
+	Filename   string   `xml:"filename,attr"`
+	LineRate   float64  `xml:"line-rate,attr"`
+	BranchRate float64  `xml:"branch-rate,attr"`
+	Complexity float64  `xml:"complexity,attr"`
+	Methods    []Method `xml:"methods>method"`
+	Lines      []Line   `xml:"lines>line"`
+}
+
+// Method holds the coverage of a single function or method
+type Method struct {
+	Name       string  `xml:"name,attr"`
+	Signature  string  `xml:"signature,attr"`
+	LineRate   float64 `xml:"line-rate,attr"`
+	BranchRate float64 `xml:"branch-rate,attr"`
+	Complexity float64 `xml:"complexity,attr"`
+	Lines      []Line  `xml:"lines>line"`
+}
+
+// Line holds the hits of a single source line
+type Line struct {
+	Number            int    `xml:"number,attr"`
+	Hits              int    `xml:"hits,attr"`
+	Branch            bool   `xml:"branch,attr"`
+	ConditionCoverage string `xml:"condition-coverage,attr,omitempty"`
+}
+
+// counts accumulates line and branch totals
+type counts struct {
+	linesValid, linesCovered       int
+	branchesValid, branchesCovered int
+}
+
+func (c *counts) add(o counts) {
+	c.linesValid += o.linesValid
+	c.linesCovered += o.linesCovered
+	c.branchesValid += o.branchesValid
+	c.branchesCovered += o.branchesCovered
+}
+
+// addLine counts a single line, branch lines have two conditions
+func (c *counts) addLine(line Line) {
+	c.linesValid++
+	if line.Hits > 0 {
+		c.linesCovered++
+	}
+	if line.Branch {
+		c.branchesValid += 2
+		c.branchesCovered++
+	}
+}
+
+func (c counts) lineRate() float64 {
+	return rate(c.linesCovered, c.linesValid)
+}
+
+// branchRate returns the ratio of covered branches, 1 without branches as
+// Cobertura consumers read 0 as uncovered branches
+func (c counts) branchRate() float64 {
+	if c.branchesValid == 0 {
+		return 1
+	}
+
+	return rate(c.branchesCovered, c.branchesValid)
+}
+
+// Generate writes the Cobertura XML report to the output directory
+func Generate(files []*coverage.FileMetrics, outDir string, module string, srcRoot string) error {
+	if err := os.MkdirAll(outDir, 0o755); err != nil {
+		return fmt.Errorf("failed to create output directory: %w", err)
+	}
+
+	report, err := Build(files, module, srcRoot)
+	if err != nil {
+		return err
+	}
+
+	out, err := xml.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal cobertura report: %w", err)
+	}
+
+	content := xml.Header + docType + "\n" + string(out) + "\n"
+	outPath := filepath.Join(outDir, "cobertura.xml")
+	if err := os.WriteFile(outPath, []byte(content), 0o644); err != nil {
+		return fmt.Errorf("failed to write cobertura report: %w", err)
+	}
+
+	return nil
+}
+
+// Build creates the Cobertura report from the file metrics. Every directory
+// of the file tree holding files becomes a package named by its import
+// path within the module holding it, every file a class. Partial lines are
+// reported as branch lines with one of two conditions covered, as the
+// profile carries no branch details.
+func Build(files []*coverage.FileMetrics, module string, srcRoot string) (*Coverage, error) {
+	source, err := filepath.Abs(srcRoot)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve source root: %w", err)
+	}
+
+	report := &Coverage{
+		Version:   version.Release(),
+		Timestamp: time.Now().UnixMilli(),
+		Sources:   []string{source},
+	}
+
+	var total counts
+	var walk func(node *tree.Node, path string)
+	walk = func(node *tree.Node, path string) {
+		var pkgCounts counts
+		pkg := Package{Name: coverage.ImportPath(module, path)}
+
+		for _, child := range node.Children {
+			if child.IsDir {
+				walk(child, child.Path)
+				continue
+			}
+
+			class, classCounts := buildClass(child.File, child.Name)
+			pkg.Classes = append(pkg.Classes, class)
+			pkgCounts.add(classCounts)
+		}
+
+		if len(pkg.Classes) == 0 {
+			return
+		}
+		if name := firstPackage(node); name != "" {
+			pkg.Name = name
+		}
+
+		pkg.LineRate = pkgCounts.lineRate()
+		pkg.BranchRate = pkgCounts.branchRate()
+		report.Packages = append(report.Packages, pkg)
+		total.add(pkgCounts)
+	}
+	walk(tree.Build(files), "")
+
+	report.LineRate = total.lineRate()
+	report.BranchRate = total.branchRate()
+	report.LinesValid = total.linesValid
+	report.LinesCovered = total.linesCovered
+	report.BranchesValid = total.branchesValid
+	report.BranchesCovered = total.branchesCovered
+
+	return report, nil
+}
+
+// buildClass creates the class element of a single file
+func buildClass(f *coverage.FileMetrics, name string) (Class, counts) {
+	class := Class{
+		Name:     name,
+		Filename: f.LocalPath,
+		Lines:    []Line{},
+	}
+
+	var c counts
+	for ln := 1; ln < len(f.PerLineStatus); ln++ {
+		line, ok := buildLine(f, ln)
+		if !ok {
+			continue
+		}
+
+		class.Lines = append(class.Lines, line)
+		c.addLine(line)
+	}
+
+	for _, fn := range f.Functions {
+		method := Method{Name: fn.QualifiedName(), Lines: []Line{}}
+
+		var mc counts
+		for ln := fn.StartLine; ln <= fn.EndLine; ln++ {
+			line, ok := buildLine(f, ln)
+			if !ok {
+				continue
+			}
+
+			method.Lines = append(method.Lines, line)
+			mc.addLine(line)
+		}
+
+		method.LineRate = mc.lineRate()
+		method.BranchRate = mc.branchRate()
+		class.Methods = append(class.Methods, method)
+	}
+
+	class.LineRate = c.lineRate()
+	class.BranchRate = c.branchRate()
+
+	return class, c
+}
+
+// buildLine creates the line element of a tracked source line
+func buildLine(f *coverage.FileMetrics, ln int) (Line, bool) {
+	if ln < 1 || ln >= len(f.PerLineStatus) || f.PerLineStatus[ln] < 0 {
+		return Line{}, false
+	}
+
+	line := Line{Number: ln}
+	status := coverage.LineStatus(f.PerLineStatus[ln])
+
+	switch {
+	case ln < len(f.PerLineHits) && f.PerLineHits[ln] >= 0:
+		line.Hits = f.PerLineHits[ln]
+	case status != coverage.Missed:
+		line.Hits = 1
+	}
+
+	if status == coverage.Partial {
+		line.Branch = true
+		line.ConditionCoverage = "50% (1/2)"
+	}
+
+	return line, true
+}
+
+// firstPackage returns the import path of the package of the first file of
+// a directory
+func firstPackage(node *tree.Node) string {
+	for _, child := range node.Children {
+		if !child.IsDir {
+			return child.File.Package
+		}
+	}
+
+	return ""
+}
+
+// rate returns the ratio of covered to valid, or 0 if nothing is valid
+func rate(covered, valid int) float64 {
+	if valid == 0 {
+		return 0
+	}
+
+	return float64(covered) / float64(valid)
+}
@@ -0,0 +1,104 @@
+/*
+Copyright (c) Tobias Schäfer. All rights reserved.
+Licensed under the MIT license, see LICENSE in the project root for details.
+*/
+package cobertura
+
+import (
+	"encoding/xml"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/tschaefer/cover-ui/internal/coverage"
+)
+
+func testFiles() []*coverage.FileMetrics {
+	return []*coverage.FileMetrics{
+		{
+			FileName:      "github.com/test/repo/main.go",
+			LocalPath:     "main.go",
+			PerLineStatus: []int{-1, -1, int(coverage.Covered), int(coverage.Missed)},
+			Functions: []coverage.FunctionMetrics{
+				{Name: "main", StartLine: 2, EndLine: 4},
+			},
+		},
+		{
+			FileName:      "github.com/test/repo/pkg/utils.go",
+			LocalPath:     "pkg/utils.go",
+			PerLineStatus: []int{-1, int(coverage.Partial), int(coverage.Covered)},
+			PerLineHits:   []int{-1, 3, 12},
+		},
+	}
+}
+
+func TestBuild(t *testing.T) {
+	report, err := Build(testFiles(), "github.com/test/repo", ".")
+	if err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+
+	if len(report.Packages) != 2 {
+		t.Fatalf("Expected 2 packages, got %d", len(report.Packages))
+	}
+	if report.Packages[0].Name != "github.com/test/repo/pkg" {
+		t.Errorf("Expected first package github.com/test/repo/pkg, got %s", report.Packages[0].Name)
+	}
+	if report.Packages[1].Name != "github.com/test/repo" {
+		t.Errorf("Expected second package github.com/test/repo, got %s", report.Packages[1].Name)
+	}
+
+	if report.LinesValid != 4 || report.LinesCovered != 3 {
+		t.Errorf("Expected 3 of 4 lines covered, got %d of %d", report.LinesCovered, report.LinesValid)
+	}
+	if report.BranchesValid != 2 || report.BranchesCovered != 1 {
+		t.Errorf("Expected 1 of 2 branches covered, got %d of %d", report.BranchesCovered, report.BranchesValid)
+	}
+
+	utils := report.Packages[0].Classes[0]
+	if utils.Filename != "pkg/utils.go" {
+		t.Errorf("Expected class filename pkg/utils.go, got %s", utils.Filename)
+	}
+	want := []Line{
+		{Number: 1, Hits: 3, Branch: true, ConditionCoverage: "50% (1/2)"},
+		{Number: 2, Hits: 12},
+	}
+	for i, w := range want {
+		if utils.Lines[i] != w {
+			t.Errorf("Expected line %+v, got %+v", w, utils.Lines[i])
+		}
+	}
+
+	main := report.Packages[1].Classes[0]
+	if len(main.Methods) != 1 || main.Methods[0].LineRate != 0.5 {
+		t.Errorf("Expected method main with line rate 0.5, got %+v", main.Methods)
+	}
+	if main.BranchRate != 1 || report.Packages[1].BranchRate != 1 {
+		t.Errorf("Expected branch rate 1 without branches, got %g and %g", main.BranchRate, report.Packages[1].BranchRate)
+	}
+	if report.BranchRate != 0.5 {
+		t.Errorf("Expected branch rate 0.5, got %g", report.BranchRate)
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	outDir := t.TempDir()
+
+	err := Generate(testFiles(), outDir, "github.com/test/repo", ".")
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+
+	content, err := os.ReadFile(filepath.Join(outDir, "cobertura.xml"))
+	if err != nil {
+		t.Fatalf("Expected cobertura.xml to exist: %v", err)
+	}
+
+	var report Coverage
+	if err := xml.Unmarshal(content, &report); err != nil {
+		t.Fatalf("Expected valid XML, got %v", err)
+	}
+	if len(report.Packages) != 2 {
+		t.Errorf("Expected 2 packages, got %d", len(report.Packages))
+	}
+}
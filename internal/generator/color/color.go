@@ -0,0 +1,62 @@
+/*
+Copyright (c) Tobias Schäfer. All rights reserved.
+Licensed under the MIT license, see LICENSE in the project root for details.
+*/
+package color
+
+import (
+	"fmt"
+	"math"
+)
+
+// RGB is a color of 8 bit channels
+type RGB struct {
+	R, G, B int
+}
+
+// Band colors, the same as COLOR_CONFIG of the index page
+var (
+	Red    = RGB{239, 68, 68}
+	Yellow = RGB{245, 158, 11}
+	Green  = RGB{34, 197, 94}
+)
+
+// Coverage returns the red/yellow/green band color of a coverage
+// percentage, matching ColorUtils.getCoverageColr of the index page: up to
+// 50% green and blue pass from red to yellow with red kept, above 50% all
+// channels pass from yellow to green
+func Coverage(pct float64) RGB {
+	pct = math.Max(0, math.Min(100, pct))
+
+	if pct <= 50 {
+		ratio := pct / 50
+		return RGB{
+			R: Red.R,
+			G: interpolate(Red.G, Yellow.G, ratio),
+			B: interpolate(Red.B, Yellow.B, ratio),
+		}
+	}
+
+	ratio := (pct - 50) / 50
+	return RGB{
+		R: interpolate(Yellow.R, Green.R, ratio),
+		G: interpolate(Yellow.G, Green.G, ratio),
+		B: interpolate(Yellow.B, Green.B, ratio),
+	}
+}
+
+// CSS returns the color in CSS functional notation
+func (c RGB) CSS() string {
+	return fmt.Sprintf("rgb(%d, %d, %d)", c.R, c.G, c.B)
+}
+
+// Hex returns the color as hex triplet
+func (c RGB) Hex() string {
+	return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+}
+
+// interpolate returns the rounded channel value at ratio between start and
+// end
+func interpolate(start, end int, ratio float64) int {
+	return int(math.Round(float64(start) + float64(end-start)*ratio))
+}
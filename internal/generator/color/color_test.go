@@ -0,0 +1,34 @@
+/*
+Copyright (c) Tobias Schäfer. All rights reserved.
+Licensed under the MIT license, see LICENSE in the project root for details.
+*/
+package color
+
+import "testing"
+
+func TestCoverage(t *testing.T) {
+	tests := []struct {
+		pct  float64
+		want string
+	}{
+		{-10, "rgb(239, 68, 68)"},
+		{0, "rgb(239, 68, 68)"},
+		{25, "rgb(239, 113, 40)"},
+		{50, "rgb(239, 158, 11)"},
+		{75, "rgb(140, 178, 53)"},
+		{100, "rgb(34, 197, 94)"},
+		{150, "rgb(34, 197, 94)"},
+	}
+
+	for _, tt := range tests {
+		if got := Coverage(tt.pct).CSS(); got != tt.want {
+			t.Errorf("Expected color %s for %.0f%%, got %s", tt.want, tt.pct, got)
+		}
+	}
+}
+
+func TestHex(t *testing.T) {
+	if got := Coverage(50).Hex(); got != "#ef9e0b" {
+		t.Errorf("Expected #ef9e0b, got %s", got)
+	}
+}
@@ -0,0 +1,108 @@
+/*
+Copyright (c) Tobias Schäfer. All rights reserved.
+Licensed under the MIT license, see LICENSE in the project root for details.
+*/
+package compare
+
+import (
+	_ "embed"
+	"fmt"
+	"html/template"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/tschaefer/cover-ui/internal/delta"
+	"github.com/tschaefer/cover-ui/internal/generator/base"
+)
+
+//go:embed assets/compare.html
+var compareHTML string
+
+//go:embed assets/compare.css
+var compareCSS string
+
+// Generate creates the comparison index page of two reports. Files with a
+// detail page, as reported by linked, link to it.
+func Generate(c *delta.Comparison, outDir, baseName, headName string, linked func(localPath string) bool) error {
+	if err := os.MkdirAll(outDir, 0o755); err != nil {
+		return fmt.Errorf("failed to create output directory: %w", err)
+	}
+
+	data := struct {
+		Comparison *delta.Comparison
+		BaseName   string
+		HeadName   string
+	}{
+		Comparison: c,
+		BaseName:   baseName,
+		HeadName:   headName,
+	}
+
+	tpl, err := template.New("base").Funcs(template.FuncMap{
+		"deltaClass":  __DeltaClass,
+		"formatDelta": __FormatDelta,
+		"indent":      __Indent,
+		"pageLink":    func(localPath string) string { return __PageLink(localPath, linked) },
+	}).Parse(base.HTML)
+	if err != nil {
+		return err
+	}
+	tpl, err = tpl.Parse(compareHTML)
+	if err != nil {
+		return err
+	}
+
+	cssPath := filepath.Join(outDir, "style.css")
+	if err := os.WriteFile(cssPath, []byte(base.CSS+"\n\n"+compareCSS), 0o644); err != nil {
+		return fmt.Errorf("failed to write CSS file: %w", err)
+	}
+
+	w, err := os.Create(filepath.Join(outDir, "index.html"))
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = w.Close()
+	}()
+
+	return tpl.Execute(w, data)
+}
+
+// Template helper functions
+
+// __DeltaClass adds a CSS class for a rising, falling or unchanged coverage
+func __DeltaClass(d float64) string {
+	switch {
+	case d > 0:
+		return "delta-up"
+	case d < 0:
+		return "delta-down"
+	default:
+		return "delta-none"
+	}
+}
+
+// __FormatDelta formats a coverage change in percentage points with sign
+func __FormatDelta(d float64) string {
+	if d == 0 {
+		return "±0.0"
+	}
+
+	return fmt.Sprintf("%+.1f", d)
+}
+
+// __Indent indents a table cell by the depth of the entry
+func __Indent(depth int) template.CSS {
+	return template.CSS(fmt.Sprintf("padding-left: %dpx", 12+depth*20))
+}
+
+// __PageLink returns the link of the detail page of a file or an empty
+// string for files without one
+func __PageLink(localPath string, linked func(string) bool) string {
+	if linked == nil || !linked(localPath) {
+		return ""
+	}
+
+	return "tree/" + strings.TrimSuffix(localPath, ".go") + ".html"
+}
@@ -0,0 +1,63 @@
+/*
+Copyright (c) Tobias Schäfer. All rights reserved.
+Licensed under the MIT license, see LICENSE in the project root for details.
+*/
+package compare
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/tschaefer/cover-ui/internal/coverage"
+	"github.com/tschaefer/cover-ui/internal/delta"
+)
+
+func TestGenerate(t *testing.T) {
+	outDir := t.TempDir()
+
+	base := []*coverage.FileMetrics{
+		{LocalPath: "pkg/a.go", TotalStmts: 4, CoveredStmts: 1, CoveragePct: 25, PerLineStatus: []int{-1, 0}},
+	}
+	head := []*coverage.FileMetrics{
+		{LocalPath: "pkg/a.go", TotalStmts: 4, CoveredStmts: 3, CoveragePct: 75, PerLineStatus: []int{-1, 2}},
+		{LocalPath: "pkg/b.go", TotalStmts: 1, CoveredStmts: 1, CoveragePct: 100},
+	}
+	c := delta.Compare(base, head)
+
+	linked := func(localPath string) bool { return localPath == "pkg/a.go" }
+	if err := Generate(c, outDir, "main.out", "branch.out", linked); err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+
+	content, err := os.ReadFile(filepath.Join(outDir, "index.html"))
+	if err != nil {
+		t.Fatalf("Failed to read index.html: %v", err)
+	}
+	for _, want := range []string{
+		"main.out → branch.out",
+		`<a class="tree-name" href="tree/pkg/a.html">a.go</a>`,
+		`<span class="tree-name">b.go</span>`,
+		`<span class="delta-up">&#43;50.0</span>`,
+		`<span class="delta-none">new</span>`,
+	} {
+		if !strings.Contains(string(content), want) {
+			t.Errorf("Expected index.html to contain %q", want)
+		}
+	}
+
+	if _, err := os.Stat(filepath.Join(outDir, "style.css")); err != nil {
+		t.Errorf("Expected style.css to exist: %v", err)
+	}
+}
+
+func TestFormatDelta(t *testing.T) {
+	tests := map[float64]string{0: "±0.0", 1.25: "+1.2", -3: "-3.0"}
+
+	for d, want := range tests {
+		if got := __FormatDelta(d); got != want {
+			t.Errorf("Expected %q for %.2f, got %q", want, d, got)
+		}
+	}
+}
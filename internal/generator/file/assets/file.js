@@ -113,7 +113,7 @@ const LineHighlighter = (() => {
     }
   }
 
-  return { toggleHighlight, applyHighlight, scrollToLine };
+  return { toggleHighlight, clearHighlight, applyHighlight, scrollToLine };
 })();
 
 // Application Initialization
@@ -128,9 +128,24 @@ function init() {
   }
 }
 
+// Follow in-page links to lines, e.g. from the function outline
+function onHashChange() {
+  const lineNum = URLManager.getLineNumberFromHash();
+  if (!lineNum || lineNum === state.currentHighlightedLine) {
+    return;
+  }
+
+  LineHighlighter.clearHighlight(state.currentHighlightedLine);
+  if (LineHighlighter.applyHighlight(lineNum)) {
+    state.currentHighlightedLine = lineNum;
+    LineHighlighter.scrollToLine(lineNum);
+  }
+}
+
 // Make toggleHighlight available globally for onclick handlers in HTML
 window.toggleHighlight = LineHighlighter.toggleHighlight;
 
 // Initialize on page load
 window.addEventListener('DOMContentLoaded', init);
+window.addEventListener('hashchange', onHashChange);
 
@@ -5,16 +5,25 @@ Licensed under the MIT license, see LICENSE in the project root for details.
 package file
 
 import (
+	"bytes"
 	_ "embed"
+	"encoding/json"
 	"fmt"
-	"html"
 	"html/template"
+	"math"
 	"os"
 	"path/filepath"
+	"runtime/debug"
+	"slices"
 	"strings"
+	"sync"
 
 	"github.com/tschaefer/cover-ui/internal/coverage"
 	"github.com/tschaefer/cover-ui/internal/generator/base"
+	"github.com/tschaefer/cover-ui/internal/generator/color"
+	"github.com/tschaefer/cover-ui/internal/manifest"
+	"github.com/tschaefer/cover-ui/internal/source"
+	"github.com/tschaefer/cover-ui/internal/version"
 )
 
 //go:embed assets/file.html
@@ -26,32 +35,205 @@ var fileCSS string
 //go:embed assets/file.js
 var fileJS string
 
-// Generate creates a file detail page
-func Generate(f *coverage.FileMetrics, filesDir string) error {
+// page holds the data of a file detail page
+type page struct {
+	File       *coverage.FileMetrics
+	Lines      []template.HTML
+	MaxHits    int
+	Changed    map[int]bool
+	Ignored    map[int]bool
+	Deltas     map[int]string
+	IndexPath  string
+	CSSPath    string
+	ScriptPath string
+}
+
+// pageTemplate parses the detail page template once, the parsed template
+// is shared by concurrent Generate calls
+var pageTemplate = sync.OnceValues(func() (*template.Template, error) {
+	tpl, err := template.New("base").Funcs(template.FuncMap{
+		"lineClass":     __AddSourceLineClass,
+		"lineMarker":    __AddLineMarker,
+		"inc":           __IncByOne,
+		"coverageColor": coverageColor,
+		"heatClass":     __AddHeatClass,
+		"hitsTitle":     __AddHitsTitle,
+		"changedClass":  __AddChangedClass,
+		"ignoredClass":  __AddIgnoredClass,
+		"deltaClass":    __AddDeltaClass,
+	}).Parse(base.HTML)
+	if err != nil {
+		return nil, err
+	}
+
+	return tpl.Parse(fileHTML)
+})
+
+// Generate creates a file detail page, the source is read by the resolver.
+// Generate is safe for concurrent use.
+func Generate(f *coverage.FileMetrics, filesDir string, src *source.Resolver) error {
 	if err := os.MkdirAll(filesDir, 0o755); err != nil {
 		return fmt.Errorf("failed to create files directory: %w", err)
 	}
 
-	source, err := os.ReadFile(f.LocalPath)
+	source, err := src.ReadFile(f.LocalPath)
 	if err != nil {
-		return fmt.Errorf("failed to read source file %q: %w", f.LocalPath, err)
-	}
-
-	data := struct {
-		File  *coverage.FileMetrics
-		Lines []string
-	}{
-		File:  f,
-		Lines: getLines(source),
+		return err
 	}
 
-	if err := writeHTMLFile(data, filesDir, f); err != nil {
+	if err := writeHTMLFile(newPage(f, highlight(source)), filesDir); err != nil {
 		return fmt.Errorf("failed to write file detail page for %q: %w", f.LocalPath, err)
 	}
 
 	return nil
 }
 
+// GenerateAll creates the detail pages of the files with a pool of at most
+// workers goroutines. done is called concurrently after every page. The
+// returned errors match the files, nil for generated pages.
+func GenerateAll(files []*coverage.FileMetrics, filesDir string, src *source.Resolver, workers int, done func(f *coverage.FileMetrics, err error)) []error {
+	errs := make([]error, len(files))
+	parallel(len(files), workers, func(i int) {
+		errs[i] = Generate(files[i], filesDir, src)
+		if done != nil {
+			done(files[i], errs[i])
+		}
+	})
+
+	return errs
+}
+
+// Fragment renders the content of a file detail page without the
+// surrounding page, e.g. to embed it into a single-file report
+func Fragment(f *coverage.FileMetrics, src *source.Resolver) ([]byte, error) {
+	source, err := src.ReadFile(f.LocalPath)
+	if err != nil {
+		return nil, err
+	}
+
+	tpl, err := pageTemplate()
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	if err := tpl.ExecuteTemplate(&buf, "content", newPage(f, highlight(source))); err != nil {
+		return nil, fmt.Errorf("failed to render file detail page for %q: %w", f.LocalPath, err)
+	}
+
+	return buf.Bytes(), nil
+}
+
+// FragmentAll renders the page contents of the files like GenerateAll. The
+// returned fragments and errors match the files.
+func FragmentAll(files []*coverage.FileMetrics, src *source.Resolver, workers int, done func(f *coverage.FileMetrics, err error)) ([][]byte, []error) {
+	fragments := make([][]byte, len(files))
+	errs := make([]error, len(files))
+	parallel(len(files), workers, func(i int) {
+		fragments[i], errs[i] = Fragment(files[i], src)
+		if done != nil {
+			done(files[i], errs[i])
+		}
+	})
+
+	return fragments, errs
+}
+
+// parallel calls fn for the indexes up to n with a pool of at most workers
+// goroutines
+func parallel(n, workers int, fn func(i int)) {
+	jobs := make(chan int)
+
+	var wg sync.WaitGroup
+	for range max(1, min(workers, n)) {
+		wg.Go(func() {
+			for i := range jobs {
+				fn(i)
+			}
+		})
+	}
+
+	for i := range n {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+}
+
+// CSS returns the stylesheet of the detail pages without the base styles
+func CSS() string {
+	return fileCSS
+}
+
+// Fingerprint returns the hash of the page templates and the generator
+// build, pages of another fingerprint are stale
+func Fingerprint() string {
+	return manifest.Hash([]byte(base.HTML), []byte(fileHTML), []byte(version.Release()), []byte(version.Commit()), []byte(buildID()))
+}
+
+// buildID identifies the running binary, as release and commit are unset
+// in go install and go run builds: the hash of the executable or, if it
+// cannot be read, the version and checksum of the main module
+var buildID = sync.OnceValue(func() string {
+	if exe, err := os.Executable(); err == nil {
+		if content, err := os.ReadFile(exe); err == nil {
+			return manifest.Hash(content)
+		}
+	}
+	if info, ok := debug.ReadBuildInfo(); ok {
+		return info.Main.Version + " " + info.Main.Sum
+	}
+
+	return ""
+})
+
+// InputHash returns the hash of the inputs of a file page, its source and
+// its metrics
+func InputHash(f *coverage.FileMetrics, source []byte) (string, error) {
+	metrics, err := json.Marshal(f)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal metrics of %q: %w", f.LocalPath, err)
+	}
+
+	return manifest.Hash(source, metrics), nil
+}
+
+// PagePath returns the path of the detail page of a file
+func PagePath(filesDir, localPath string) string {
+	outPath := filepath.Join(filesDir, localPath)
+	return strings.TrimSuffix(outPath, filepath.Ext(outPath)) + ".html"
+}
+
+// newPage returns the page data of a file with its highlighted lines
+func newPage(f *coverage.FileMetrics, lines []template.HTML) *page {
+	p := &page{
+		File:       f,
+		Lines:      lines,
+		MaxHits:    slices.Max(append([]int{0}, f.PerLineHits...)),
+		Changed:    make(map[int]bool, len(f.ChangedLines)),
+		Ignored:    make(map[int]bool, len(f.IgnoredLines)),
+		Deltas:     make(map[int]string, len(f.NewlyCoveredLines)+len(f.NewlyUncoveredLines)),
+		IndexPath:  __GetRelativePath(f.LocalPath, "../index.html"),
+		CSSPath:    __GetRelativePath(f.LocalPath, "style.css"),
+		ScriptPath: __GetRelativePath(f.LocalPath, "script.js"),
+	}
+
+	for _, ln := range f.ChangedLines {
+		p.Changed[ln] = true
+	}
+	for _, ln := range f.IgnoredLines {
+		p.Ignored[ln] = true
+	}
+	for _, ln := range f.NewlyCoveredLines {
+		p.Deltas[ln] = "newly-covered"
+	}
+	for _, ln := range f.NewlyUncoveredLines {
+		p.Deltas[ln] = "newly-uncovered"
+	}
+
+	return p
+}
+
 // Assets writes the css and javascript files to the output directory
 func Assets(filesDir string) error {
 	if err := os.MkdirAll(filesDir, 0o755); err != nil {
@@ -72,38 +254,14 @@ func Assets(filesDir string) error {
 	return nil
 }
 
-// getLines splits the source into lines and removes trailing empty ones
-func getLines(source []byte) []string {
-	lines := strings.Split(string(source), "\n")
-
-	for len(lines) > 0 && lines[len(lines)-1] == "" {
-		lines = lines[:len(lines)-1]
-	}
-
-	return lines
-}
-
 // writeHTMLFile writes the detail HTML page
-func writeHTMLFile(data any, filesDir string, f *coverage.FileMetrics) error {
-	tpl, err := template.New("base").Funcs(template.FuncMap{
-		"escape":     __EscapeSourceLine,
-		"lineClass":  __AddSourceLineClass,
-		"lineMarker": __AddLineMarker,
-		"inc":        __IncByOne,
-		"indexPath":  func() string { return __GetRelativePath(f.LocalPath, "../index.html") },
-		"cssPath":    func() string { return __GetRelativePath(f.LocalPath, "style.css") },
-		"scriptPath": func() string { return __GetRelativePath(f.LocalPath, "script.js") },
-	}).Parse(base.HTML)
-	if err != nil {
-		return err
-	}
-	tpl, err = tpl.Parse(fileHTML)
+func writeHTMLFile(p *page, filesDir string) error {
+	tpl, err := pageTemplate()
 	if err != nil {
 		return err
 	}
 
-	outPath := filepath.Join(filesDir, f.LocalPath)
-	outPath = strings.TrimSuffix(outPath, filepath.Ext(outPath)) + ".html"
+	outPath := PagePath(filesDir, p.File.LocalPath)
 
 	if err := os.MkdirAll(filepath.Dir(outPath), 0o755); err != nil {
 		return err
@@ -117,20 +275,11 @@ func writeHTMLFile(data any, filesDir string, f *coverage.FileMetrics) error {
 		_ = w.Close()
 	}()
 
-	return tpl.Execute(w, data)
+	return tpl.Execute(w, p)
 }
 
 // Template helper functions
 
-// __EscapesSourceLines escapes source line for HTML output
-func __EscapeSourceLine(str string) template.HTML {
-	if str == "" {
-		return template.HTML("&nbsp;")
-	}
-
-	return template.HTML(html.EscapeString(str))
-}
-
 // __AddSourceLineClass adds a CSS class based on the coverage status of a
 // source code line
 func __AddSourceLineClass(idx int, statuses []int) string {
@@ -162,6 +311,67 @@ func __AddLineMarker(idx int, statuses []int) string {
 	}
 }
 
+// heatLevels is the number of steps of the hit count heat gradient
+const heatLevels = 5
+
+// __AddHeatClass adds a CSS class for the hit count heat level of a source
+// code line, scaled logarithmically to the hottest line of the file
+func __AddHeatClass(idx int, hits []int, maxHits int) string {
+	if idx < 1 || idx >= len(hits) || hits[idx] <= 0 {
+		return ""
+	}
+
+	level := 1
+	if maxHits > 1 {
+		level += int(float64(heatLevels-1) * math.Log(float64(hits[idx])) / math.Log(float64(maxHits)))
+	}
+
+	return fmt.Sprintf("heat-%d", min(level, heatLevels))
+}
+
+// __AddHitsTitle adds a tooltip text with the hit count of a source code line
+func __AddHitsTitle(idx int, hits []int) string {
+	if idx < 1 || idx >= len(hits) || hits[idx] < 0 {
+		return ""
+	}
+
+	if hits[idx] == 1 {
+		return "1 hit"
+	}
+
+	return fmt.Sprintf("%d hits", hits[idx])
+}
+
+// __AddChangedClass adds a CSS class for lines changed against the diff base
+func __AddChangedClass(idx int, changed map[int]bool) string {
+	if changed[idx] {
+		return "changed"
+	}
+
+	return ""
+}
+
+// __AddIgnoredClass adds a CSS class for lines excluded by ignore directives
+func __AddIgnoredClass(idx int, ignored map[int]bool) string {
+	if ignored[idx] {
+		return "ignored"
+	}
+
+	return ""
+}
+
+// __AddDeltaClass adds a CSS class for lines that became covered or
+// uncovered against a compared report
+func __AddDeltaClass(idx int, deltas map[int]string) string {
+	return deltas[idx]
+}
+
+// coverageColor returns the red/yellow/green band color for a coverage
+// percentage, the same as on the index page
+func coverageColor(pct float64) template.CSS {
+	return template.CSS(color.Coverage(pct).CSS())
+}
+
 // __IncByOne increments an integer by one
 func __IncByOne(i int) int {
 	return i + 1
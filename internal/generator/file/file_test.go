@@ -8,9 +8,11 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 	"testing"
 
 	"github.com/tschaefer/cover-ui/internal/coverage"
+	"github.com/tschaefer/cover-ui/internal/source"
 )
 
 func TestAssets(t *testing.T) {
@@ -60,7 +62,7 @@ func main() {
 		LocalPath: sourceFile.Name(),
 	}
 
-	err = Generate(fileMetrics, filesDir)
+	err = Generate(fileMetrics, filesDir, source.New("."))
 	if err != nil {
 		t.Fatalf("Generate() returned an error: %v", err)
 	}
@@ -70,3 +72,156 @@ func main() {
 		t.Errorf("Expected generated HTML file does not exist: %s", generatedFilePath)
 	}
 }
+
+func TestGenerateAll(t *testing.T) {
+	srcDir := t.TempDir()
+	filesDir := filepath.Join(t.TempDir(), "tree")
+
+	var files []*coverage.FileMetrics
+	for _, name := range []string{"a.go", "pkg/b.go", "pkg/c.go", "missing.go"} {
+		if name != "missing.go" {
+			if err := os.MkdirAll(filepath.Join(srcDir, filepath.Dir(name)), 0o755); err != nil {
+				t.Fatalf("Failed to create source directory: %v", err)
+			}
+			if err := os.WriteFile(filepath.Join(srcDir, name), []byte("package main\n"), 0o644); err != nil {
+				t.Fatalf("Failed to write source file: %v", err)
+			}
+		}
+		files = append(files, &coverage.FileMetrics{LocalPath: name})
+	}
+
+	var mu sync.Mutex
+	done := 0
+	errs := GenerateAll(files, filesDir, source.New(srcDir), 2, func(f *coverage.FileMetrics, err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		done++
+	})
+
+	if done != len(files) {
+		t.Errorf("Expected done to be called %d times, got %d", len(files), done)
+	}
+	for i, f := range files[:3] {
+		if errs[i] != nil {
+			t.Errorf("Expected no error for %s, got %v", f.LocalPath, errs[i])
+		}
+		if _, err := os.Stat(PagePath(filesDir, f.LocalPath)); err != nil {
+			t.Errorf("Expected page of %s, got %v", f.LocalPath, err)
+		}
+	}
+	if errs[3] == nil {
+		t.Errorf("Expected error for missing source file")
+	}
+}
+
+func TestFragment(t *testing.T) {
+	srcDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(srcDir, "main.go"), []byte("package main\n\nfunc main() {}\n"), 0o644); err != nil {
+		t.Fatalf("Failed to write source file: %v", err)
+	}
+
+	files := []*coverage.FileMetrics{
+		{LocalPath: "main.go", PerLineStatus: []int{-1, -1, int(coverage.Covered)}},
+		{LocalPath: "missing.go"},
+	}
+
+	fragments, errs := FragmentAll(files, source.New(srcDir), 2, nil)
+	if errs[0] != nil {
+		t.Fatalf("Expected no error for main.go, got %v", errs[0])
+	}
+	if errs[1] == nil {
+		t.Errorf("Expected error for missing source file")
+	}
+
+	fragment := string(fragments[0])
+	if !strings.Contains(fragment, `id="line-3"`) || !strings.Contains(fragment, `class="panel outline"`) {
+		t.Errorf("Expected page content in fragment, got %s", fragment)
+	}
+	if strings.Contains(fragment, "<html>") || strings.Contains(fragment, "<script") {
+		t.Errorf("Expected fragment without surrounding page, got %s", fragment)
+	}
+}
+
+func TestInputHash(t *testing.T) {
+	f := &coverage.FileMetrics{LocalPath: "main.go", PerLineStatus: []int{-1, 1}}
+
+	hash, err := InputHash(f, []byte("package main"))
+	if err != nil {
+		t.Fatalf("InputHash failed: %v", err)
+	}
+
+	if other, _ := InputHash(f, []byte("package other")); other == hash {
+		t.Error("Expected changed source to change the hash")
+	}
+
+	f.PerLineStatus = []int{-1, 0}
+	if other, _ := InputHash(f, []byte("package main")); other == hash {
+		t.Error("Expected changed coverage to change the hash")
+	}
+}
+
+func TestPagePath(t *testing.T) {
+	if got, want := PagePath("out/tree", "pkg/server.go"), filepath.Join("out", "tree", "pkg", "server.html"); got != want {
+		t.Errorf("Expected %s, got %s", want, got)
+	}
+}
+
+func TestCoverageColor(t *testing.T) {
+	tests := []struct {
+		pct  float64
+		want string
+	}{
+		{0, "rgb(239, 68, 68)"},
+		{50, "rgb(239, 158, 11)"},
+		{100, "rgb(34, 197, 94)"},
+		{150, "rgb(34, 197, 94)"},
+	}
+
+	for _, tt := range tests {
+		if got := string(coverageColor(tt.pct)); got != tt.want {
+			t.Errorf("Expected color %s for %.0f%%, got %s", tt.want, tt.pct, got)
+		}
+	}
+}
+
+func TestHeatClass(t *testing.T) {
+	hits := []int{0, -1, 0, 1, 10, 1000}
+
+	want := []string{"", "", "", "heat-1", "heat-2", "heat-5"}
+	for idx, w := range want {
+		if got := __AddHeatClass(idx, hits, 1000); got != w {
+			t.Errorf("Expected heat class %q for line %d, got %q", w, idx, got)
+		}
+	}
+}
+
+func TestHitsTitle(t *testing.T) {
+	hits := []int{0, -1, 0, 1, 42}
+
+	want := []string{"", "", "0 hits", "1 hit", "42 hits"}
+	for idx, w := range want {
+		if got := __AddHitsTitle(idx, hits); got != w {
+			t.Errorf("Expected title %q for line %d, got %q", w, idx, got)
+		}
+	}
+}
+
+func TestIgnoredClass(t *testing.T) {
+	ignored := map[int]bool{3: true}
+
+	if got := __AddIgnoredClass(3, ignored); got != "ignored" {
+		t.Errorf("Expected class ignored for line 3, got %q", got)
+	}
+	if got := __AddIgnoredClass(4, ignored); got != "" {
+		t.Errorf("Expected no class for line 4, got %q", got)
+	}
+}
+
+func TestFingerprint(t *testing.T) {
+	if buildID() == "" {
+		t.Error("Expected build ID of the test binary, got none")
+	}
+	if Fingerprint() != Fingerprint() {
+		t.Error("Expected stable fingerprint")
+	}
+}
@@ -0,0 +1,146 @@
+/*
+Copyright (c) Tobias Schäfer. All rights reserved.
+Licensed under the MIT license, see LICENSE in the project root for details.
+*/
+package file
+
+import (
+	"bytes"
+	"go/scanner"
+	"go/token"
+	"html"
+	"html/template"
+	"strings"
+)
+
+// highlight tokenizes the Go source and returns one HTML line per source
+// line with the tokens wrapped in spans. Tokens spanning several lines,
+// like raw strings and block comments, are closed at the end of each line
+// and reopened on the next one. Trailing empty lines are removed.
+func highlight(source []byte) []template.HTML {
+	h := &highlighter{}
+
+	fset := token.NewFileSet()
+	file := fset.AddFile("", fset.Base(), len(source))
+
+	var s scanner.Scanner
+	s.Init(file, source, nil, scanner.ScanComments)
+
+	cursor := 0
+	for {
+		pos, tok, lit := s.Scan()
+		if tok == token.EOF {
+			break
+		}
+		// Automatically inserted semicolons have no source text
+		if tok == token.SEMICOLON && lit != ";" {
+			continue
+		}
+
+		start := file.Offset(pos)
+		end := tokenEnd(source, start, tok, lit)
+		if start < cursor || end > len(source) {
+			continue
+		}
+
+		h.emit(string(source[cursor:start]), "")
+		h.emit(string(source[start:end]), tokenClass(tok))
+		cursor = end
+	}
+	h.emit(string(source[cursor:]), "")
+
+	lines := h.finish()
+	for len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+
+	result := make([]template.HTML, len(lines))
+	for i, line := range lines {
+		if line == "" {
+			line = "&nbsp;"
+		}
+		result[i] = template.HTML(line)
+	}
+
+	return result
+}
+
+// tokenEnd returns the source offset following a token. Comments and raw
+// strings are measured in the source, as the scanner strips carriage
+// returns from their literals.
+func tokenEnd(source []byte, start int, tok token.Token, lit string) int {
+	switch {
+	case tok == token.COMMENT && strings.HasPrefix(lit, "//"):
+		if idx := bytes.IndexByte(source[start:], '\n'); idx >= 0 {
+			return start + idx
+		}
+		return len(source)
+	case tok == token.COMMENT:
+		if idx := bytes.Index(source[start+2:], []byte("*/")); idx >= 0 {
+			return start + 2 + idx + 2
+		}
+		return len(source)
+	case tok == token.STRING && strings.HasPrefix(lit, "`"):
+		if idx := bytes.IndexByte(source[start+1:], '`'); idx >= 0 {
+			return start + 1 + idx + 1
+		}
+		return len(source)
+	case lit != "":
+		return start + len(lit)
+	default:
+		return start + len(tok.String())
+	}
+}
+
+// tokenClass returns the CSS class of a token, empty for operators and
+// delimiters
+func tokenClass(tok token.Token) string {
+	switch {
+	case tok.IsKeyword():
+		return "tok-keyword"
+	case tok == token.STRING || tok == token.CHAR:
+		return "tok-string"
+	case tok == token.COMMENT:
+		return "tok-comment"
+	case tok == token.INT || tok == token.FLOAT || tok == token.IMAG:
+		return "tok-number"
+	case tok == token.IDENT:
+		return "tok-ident"
+	default:
+		return ""
+	}
+}
+
+// highlighter collects the highlighted HTML lines
+type highlighter struct {
+	lines   []string
+	current strings.Builder
+}
+
+// emit appends escaped text, wrapped in a span of the class on every line
+// it touches
+func (h *highlighter) emit(text, class string) {
+	for i, part := range strings.Split(text, "\n") {
+		if i > 0 {
+			h.lines = append(h.lines, h.current.String())
+			h.current.Reset()
+		}
+		if part == "" {
+			continue
+		}
+
+		if class == "" {
+			h.current.WriteString(html.EscapeString(part))
+			continue
+		}
+
+		h.current.WriteString(`<span class="` + class + `">`)
+		h.current.WriteString(html.EscapeString(part))
+		h.current.WriteString(`</span>`)
+	}
+}
+
+// finish returns all lines including the last unterminated one
+func (h *highlighter) finish() []string {
+	return append(h.lines, h.current.String())
+}
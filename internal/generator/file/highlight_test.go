@@ -0,0 +1,52 @@
+/*
+Copyright (c) Tobias Schäfer. All rights reserved.
+Licensed under the MIT license, see LICENSE in the project root for details.
+*/
+package file
+
+import (
+	"html/template"
+	"reflect"
+	"testing"
+)
+
+func TestHighlight(t *testing.T) {
+	source := "package main\n" +
+		"\n" +
+		"// x < y\n" +
+		"var s = `a\n" +
+		"<b>`\n" +
+		"\n" +
+		"func f() int { return 42 + 'c' }\n" +
+		"\n"
+
+	want := []template.HTML{
+		`<span class="tok-keyword">package</span> <span class="tok-ident">main</span>`,
+		`&nbsp;`,
+		`<span class="tok-comment">// x &lt; y</span>`,
+		`<span class="tok-keyword">var</span> <span class="tok-ident">s</span> = <span class="tok-string">` + "`a" + `</span>`,
+		`<span class="tok-string">&lt;b&gt;` + "`" + `</span>`,
+		`&nbsp;`,
+		`<span class="tok-keyword">func</span> <span class="tok-ident">f</span>() <span class="tok-ident">int</span> { ` +
+			`<span class="tok-keyword">return</span> <span class="tok-number">42</span> + <span class="tok-string">&#39;c&#39;</span> }`,
+	}
+
+	got := highlight([]byte(source))
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected highlighted lines\n%v\ngot\n%v", want, got)
+	}
+}
+
+func TestHighlightInvalidSource(t *testing.T) {
+	source := "func \"unterminated\n/* open comment\n\tstill open"
+
+	got := highlight([]byte(source))
+	if len(got) != 3 {
+		t.Fatalf("Expected 3 lines, got %d: %v", len(got), got)
+	}
+
+	want := template.HTML(`<span class="tok-comment">	still open</span>`)
+	if got[2] != want {
+		t.Errorf("Expected last line %q, got %q", want, got[2])
+	}
+}
@@ -7,6 +7,14 @@ const DONUT_CONFIG = {
   minSliceAngle: 0.001
 };
 
+const TREND_CONFIG = {
+  width: 320,
+  height: 120,
+  padding: 12,
+  labelWidth: 36,
+  pointRadius: 3
+};
+
 const COLOR_CONFIG = {
   red: { r: 239, g: 68, b: 68 },
   yellow: { r: 245, g: 158, b: 11 },
@@ -23,7 +31,8 @@ const isTouchDevice = () => {
 // State Management
 const state = {
   currentPath: [],
-  currentNode: fileTree
+  currentNode: fileTree,
+  functionSort: { key: 'coveragePct', ascending: true }
 };
 
 // Tooltip Module
@@ -118,12 +127,25 @@ const Navigation = (() => {
     FileTreeRenderer.render();
   }
 
-  function navigateToFile(localPath) {
+  function navigateToDirectory(dir) {
+    const names = dir === '.' ? [] : dir.split('/');
+    state.currentPath = names;
+    navigateToPath(names.length - 1);
+  }
+
+  function navigateToFile(localPath, line) {
+    // Single-file reports render file pages in place
+    if (typeof SinglePage !== 'undefined') {
+      SinglePage.open(localPath, line);
+      return;
+    }
+
     const htmlPath = localPath.replace(/\.[^.]+$/, '.html');
-    window.location.href = `tree/${htmlPath}`;
+    const hash = line ? `#L${line}` : '';
+    window.location.href = `tree/${htmlPath}${hash}`;
   }
 
-  return { navigateInto, navigateToPath, navigateToFile };
+  return { navigateInto, navigateToPath, navigateToDirectory, navigateToFile };
 })();
 
 // DOM Helper Functions
@@ -189,6 +211,7 @@ const FileTreeRenderer = (() => {
     browser.appendChild(renderBreadcrumb());
     browser.appendChild(renderFileList());
     DonutChart.render();
+    FunctionTable.render();
   }
 
   function renderBreadcrumb() {
@@ -282,6 +305,144 @@ const FileTreeRenderer = (() => {
   return { render };
 })();
 
+// Function Table Renderer
+const FunctionTable = (() => {
+  const COLUMNS = [
+    { key: 'name', label: 'Function', align: 'left' },
+    { key: 'location', label: 'File', align: 'left' },
+    { key: 'totalStmts', label: 'Statements' },
+    { key: 'coveredStmts', label: 'Covered' },
+    { key: 'coveragePct', label: 'Coverage' }
+  ];
+
+  function displayName(fn) {
+    return fn.receiver ? `(${fn.receiver}).${fn.name}` : fn.name;
+  }
+
+  function collect() {
+    const prefix = state.currentPath.length > 0 ? `${state.currentPath.join('/')}/` : '';
+
+    return files
+      .filter(file => file.localPath?.startsWith(prefix))
+      .flatMap(file => (file.functions || []).map(fn => ({
+        ...fn,
+        name: displayName(fn),
+        localPath: file.localPath,
+        location: `${file.localPath}:${fn.startLine}`
+      })));
+  }
+
+  function compare(a, b) {
+    const { key, ascending } = state.functionSort;
+    const left = a[key];
+    const right = b[key];
+    const result = typeof left === 'string' ? left.localeCompare(right) : left - right;
+    return ascending ? result : -result;
+  }
+
+  function sortBy(key) {
+    if (state.functionSort.key === key) {
+      state.functionSort.ascending = !state.functionSort.ascending;
+    } else {
+      state.functionSort = { key, ascending: true };
+    }
+    render();
+  }
+
+  function render() {
+    const container = document.getElementById('function-table');
+    if (!container) return;
+
+    container.innerHTML = '';
+
+    const title = document.createElement('div');
+    title.className = 'panel-title';
+    title.textContent = 'Functions';
+    container.appendChild(title);
+
+    const functions = collect().sort(compare);
+    if (functions.length === 0) {
+      const emptyDiv = document.createElement('div');
+      emptyDiv.style.padding = '20px';
+      emptyDiv.style.color = 'var(--text-muted)';
+      emptyDiv.textContent = 'No functions';
+      container.appendChild(emptyDiv);
+      return;
+    }
+
+    const table = document.createElement('table');
+    table.className = 'file-table';
+    table.appendChild(renderHeader());
+
+    const tbody = document.createElement('tbody');
+    functions.forEach(fn => {
+      tbody.appendChild(renderRow(fn));
+    });
+    table.appendChild(tbody);
+
+    container.appendChild(table);
+  }
+
+  function renderHeader() {
+    const thead = document.createElement('thead');
+    const headerRow = document.createElement('tr');
+
+    COLUMNS.forEach(column => {
+      const th = document.createElement('th');
+      const sorted = state.functionSort.key === column.key;
+      const arrow = state.functionSort.ascending ? ' ▲' : ' ▼';
+      th.textContent = column.label + (sorted ? arrow : '');
+      th.className = `file-table-stat-header file-table-sortable${sorted ? ' sorted' : ''}`;
+      if (column.align) {
+        th.style.textAlign = column.align;
+      }
+      th.addEventListener('click', () => sortBy(column.key));
+      headerRow.appendChild(th);
+    });
+    thead.appendChild(headerRow);
+
+    return thead;
+  }
+
+  function renderRow(fn) {
+    const row = document.createElement('tr');
+    row.className = 'file-table-row';
+
+    const nameCell = document.createElement('td');
+    nameCell.className = 'file-table-name';
+    const nameSpan = document.createElement('span');
+    nameSpan.className = 'tree-name';
+    nameSpan.textContent = fn.name;
+    nameCell.appendChild(nameSpan);
+    row.appendChild(nameCell);
+
+    const locationCell = document.createElement('td');
+    locationCell.className = 'file-table-location';
+    locationCell.textContent = fn.location;
+    row.appendChild(locationCell);
+
+    row.appendChild(DOMHelpers.createStatCell(fn.totalStmts, 'Statements'));
+    row.appendChild(DOMHelpers.createStatCell(fn.coveredStmts, 'Covered'));
+
+    const pct = fn.coveragePct || 0;
+    const coverageCell = document.createElement('td');
+    coverageCell.className = 'file-table-coverage';
+    coverageCell.textContent = `${pct.toFixed(1)}%`;
+    coverageCell.style.color = ColorUtils.getCoverageColr(pct);
+    DOMHelpers.addTooltip(coverageCell, 'Coverage');
+    row.appendChild(coverageCell);
+
+    row.style.cursor = 'pointer';
+    row.addEventListener('click', () => {
+      Navigation.navigateToFile(fn.localPath, fn.startLine);
+    });
+
+    return row;
+  }
+
+  return { render };
+})();
+
 // Donut Chart Renderer
 const DonutChart = (() => {
   function render() {
@@ -430,9 +591,118 @@ const DonutChart = (() => {
   return { render };
 })();
 
+// Trend Chart Module
+const TrendChart = (() => {
+  const SVG_NS = 'http://www.w3.org/2000/svg';
+
+  function render() {
+    const svg = document.getElementById('trend');
+    if (!svg || trend.length < 2) return;
+
+    svg.innerHTML = '';
+
+    const values = trend.map(point => point.coveragePct);
+    const min = Math.max(0, Math.floor(Math.min(...values)) - 1);
+    const max = Math.min(100, Math.ceil(Math.max(...values)) + 1);
+
+    const left = TREND_CONFIG.labelWidth;
+    const right = TREND_CONFIG.width - TREND_CONFIG.padding;
+    const top = TREND_CONFIG.padding;
+    const bottom = TREND_CONFIG.height - TREND_CONFIG.padding;
+
+    const x = (i) => left + (right - left) * i / (trend.length - 1);
+    const y = (pct) => max === min ? (top + bottom) / 2 : bottom - (bottom - top) * (pct - min) / (max - min);
+
+    renderAxis(svg, min, y(min), left, right);
+    renderAxis(svg, max, y(max), left, right);
+
+    const line = document.createElementNS(SVG_NS, 'polyline');
+    line.setAttribute('points', trend.map((point, i) => `${x(i)},${y(point.coveragePct)}`).join(' '));
+    line.setAttribute('fill', 'none');
+    line.setAttribute('stroke', 'rgba(78, 161, 255, 1)');
+    line.setAttribute('stroke-width', '2');
+    svg.appendChild(line);
+
+    trend.forEach((point, i) => {
+      svg.appendChild(createPoint(point, x(i), y(point.coveragePct)));
+    });
+  }
+
+  function renderAxis(svg, pct, yPos, left, right) {
+    const axis = document.createElementNS(SVG_NS, 'line');
+    axis.setAttribute('x1', left);
+    axis.setAttribute('x2', right);
+    axis.setAttribute('y1', yPos);
+    axis.setAttribute('y2', yPos);
+    axis.setAttribute('stroke', 'rgba(255, 255, 255, 0.1)');
+    svg.appendChild(axis);
+
+    const label = document.createElementNS(SVG_NS, 'text');
+    label.setAttribute('x', left - 6);
+    label.setAttribute('y', yPos + 4);
+    label.setAttribute('fill', 'rgba(151, 160, 170, 1)');
+    label.setAttribute('font-size', '11');
+    label.setAttribute('text-anchor', 'end');
+    label.textContent = `${pct}%`;
+    svg.appendChild(label);
+  }
+
+  function createPoint(point, xPos, yPos) {
+    const circle = document.createElementNS(SVG_NS, 'circle');
+    circle.setAttribute('cx', xPos);
+    circle.setAttribute('cy', yPos);
+    circle.setAttribute('r', TREND_CONFIG.pointRadius);
+    circle.setAttribute('fill', ColorUtils.getCoverageColr(point.coveragePct));
+
+    const date = new Date(point.timestamp).toLocaleString();
+    const commit = point.commit ? ` (${point.commit.slice(0, 7)})` : '';
+    const label = `${date}${commit} - ${point.coveragePct.toFixed(1)}%`;
+
+    if (isTouchDevice()) {
+      circle.addEventListener('click', (e) => Tooltip.show(e.clientX, e.clientY, label));
+      return circle;
+    }
+
+    circle.addEventListener('mouseover', (e) => {
+      circle.setAttribute('r', TREND_CONFIG.pointRadius * 1.5);
+      Tooltip.show(e.clientX, e.clientY, label);
+    });
+    circle.addEventListener('mouseout', () => {
+      circle.setAttribute('r', TREND_CONFIG.pointRadius);
+      Tooltip.hide();
+    });
+
+    return circle;
+  }
+
+  return { render };
+})();
+
+// Colors static coverage values rendered into the page
+function colorizeCoverage() {
+  document.querySelectorAll('[data-coverage]').forEach(element => {
+    const pct = parseFloat(element.dataset.coverage) || 0;
+    element.style.color = ColorUtils.getCoverageColr(pct);
+  });
+}
+
+// Opens the directory of a module in the file browser
+function bindModuleRows() {
+  document.querySelectorAll('.module-row').forEach(row => {
+    row.style.cursor = 'pointer';
+    row.addEventListener('click', () => {
+      Navigation.navigateToDirectory(row.dataset.dir);
+      document.getElementById('file-browser').scrollIntoView({ behavior: 'smooth' });
+    });
+  });
+}
+
 // Application Initialization
 function init() {
+  colorizeCoverage();
+  bindModuleRows();
   FileTreeRenderer.render();
+  TrendChart.render();
 }
 
 init();
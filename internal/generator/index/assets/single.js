@@ -0,0 +1,193 @@
+// Single-file Report
+const SINGLE_CONFIG = {
+  hashPrefix: '#tree/',
+  linePrefix: '#L',
+  scrollBehavior: 'smooth',
+  scrollBlock: 'center'
+};
+
+// Renders the embedded file pages in place of the index, routed by the
+// location hash, e.g. #tree/pkg/server.go:42
+const SinglePage = (() => {
+  const cache = new Map();
+  const view = { path: null, line: null };
+  let renderToken = 0;
+  let indexTitle = null;
+  let indexSubheader = null;
+
+  function elements() {
+    return {
+      container: document.getElementById('file-view'),
+      nav: document.getElementById('file-nav'),
+      subheader: document.querySelector('.header .h2')
+    };
+  }
+
+  async function inflate(localPath) {
+    if (cache.has(localPath)) {
+      return cache.get(localPath);
+    }
+
+    const data = filePages[localPath];
+    if (!data) {
+      return null;
+    }
+
+    const bytes = Uint8Array.from(atob(data), c => c.charCodeAt(0));
+    const stream = new Blob([bytes]).stream().pipeThrough(new DecompressionStream('gzip'));
+    const html = await new Response(stream).text();
+    cache.set(localPath, html);
+    return html;
+  }
+
+  function hashFor(localPath, line) {
+    return SINGLE_CONFIG.hashPrefix + encodeURI(localPath) + (line ? `:${line}` : '');
+  }
+
+  function parseHash() {
+    const hash = window.location.hash;
+    if (!hash.startsWith(SINGLE_CONFIG.hashPrefix)) {
+      return null;
+    }
+
+    const match = decodeURI(hash.substring(SINGLE_CONFIG.hashPrefix.length)).match(/^(.+?)(?::(\d+))?$/);
+    if (!match) {
+      return null;
+    }
+
+    return { path: match[1], line: match[2] ? parseInt(match[2], 10) : null };
+  }
+
+  function setLineHighlight(line, highlighted) {
+    for (const id of [`line-${line}`, `linenum-${line}`]) {
+      const element = document.getElementById(id);
+      if (element) {
+        element.classList.toggle('highlighted', highlighted);
+      }
+    }
+  }
+
+  function highlight(line, scroll) {
+    if (view.line) {
+      setLineHighlight(view.line, false);
+    }
+
+    view.line = line;
+    if (!line) {
+      return;
+    }
+
+    setLineHighlight(line, true);
+    const element = document.getElementById(`line-${line}`);
+    if (scroll && element) {
+      element.scrollIntoView({
+        behavior: SINGLE_CONFIG.scrollBehavior,
+        block: SINGLE_CONFIG.scrollBlock
+      });
+    }
+  }
+
+  function showIndex() {
+    const { container, nav, subheader } = elements();
+
+    view.path = null;
+    view.line = null;
+    container.hidden = true;
+    container.innerHTML = '';
+    nav.hidden = true;
+    document.body.classList.remove('file-mode');
+    subheader.textContent = indexSubheader;
+    document.title = indexTitle;
+  }
+
+  async function showFile(route) {
+    const { container, nav, subheader } = elements();
+
+    if (route.path !== view.path) {
+      const token = ++renderToken;
+      const html = await inflate(route.path);
+      if (token !== renderToken) {
+        return;
+      }
+      if (html === null) {
+        showIndex();
+        return;
+      }
+
+      container.innerHTML = html;
+      container.hidden = false;
+      nav.hidden = false;
+      document.body.classList.add('file-mode');
+      subheader.textContent = route.path;
+      document.title = `Coverage Report - ${route.path}`;
+      view.path = route.path;
+      view.line = null;
+      window.scrollTo(0, 0);
+    }
+
+    if (route.line !== view.line) {
+      highlight(route.line, true);
+    }
+  }
+
+  function render() {
+    const route = parseHash();
+    if (!route) {
+      renderToken++;
+      if (view.path) {
+        showIndex();
+      }
+      return;
+    }
+
+    showFile(route);
+  }
+
+  function open(localPath, line) {
+    window.location.hash = hashFor(localPath, line);
+  }
+
+  // Toggles the highlight of a clicked line like the file pages do
+  function toggleHighlight(line) {
+    if (!view.path) {
+      return;
+    }
+
+    if (view.line === line) {
+      highlight(null, false);
+      window.location.hash = hashFor(view.path);
+    } else {
+      highlight(line, false);
+      window.location.hash = hashFor(view.path, line);
+    }
+  }
+
+  // Follows the in-page line links of the function outline
+  function onClick(e) {
+    const link = e.target.closest(`a[href^="${SINGLE_CONFIG.linePrefix}"]`);
+    if (!link) {
+      return;
+    }
+
+    e.preventDefault();
+    const line = parseInt(link.getAttribute('href').substring(SINGLE_CONFIG.linePrefix.length), 10);
+    open(view.path, line);
+  }
+
+  function init() {
+    const { container, subheader } = elements();
+
+    indexTitle = document.title;
+    indexSubheader = subheader.textContent;
+    container.addEventListener('click', onClick);
+    window.addEventListener('hashchange', render);
+    render();
+  }
+
+  return { init, open, toggleHighlight };
+})();
+
+// Make toggleHighlight available globally for onclick handlers in HTML
+window.toggleHighlight = SinglePage.toggleHighlight;
+
+SinglePage.init();
@@ -5,15 +5,23 @@ Licensed under the MIT license, see LICENSE in the project root for details.
 package index
 
 import (
+	"bytes"
+	"compress/gzip"
 	_ "embed"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"html/template"
 	"os"
 	"path/filepath"
+	"time"
 
 	"github.com/tschaefer/cover-ui/internal/coverage"
+	"github.com/tschaefer/cover-ui/internal/exclude"
 	"github.com/tschaefer/cover-ui/internal/generator/base"
+	"github.com/tschaefer/cover-ui/internal/generator/file"
+	"github.com/tschaefer/cover-ui/internal/history"
+	"github.com/tschaefer/cover-ui/internal/module"
 	"github.com/tschaefer/cover-ui/internal/tree"
 )
 
@@ -26,37 +34,187 @@ var indexCSS string
 //go:embed assets/index.js
 var indexJS string
 
-// Generate creates the index page
-func Generate(files []*coverage.FileMetrics, outDir string, module string) error {
-	if err := os.MkdirAll(outDir, 0o755); err != nil {
-		return fmt.Errorf("failed to create output directory: %w", err)
+//go:embed assets/single.css
+var singleCSS string
+
+//go:embed assets/single.js
+var singleJS string
+
+// trendPoint holds the totals of a history entry shown in the trend chart
+type trendPoint struct {
+	Timestamp    string  `json:"timestamp"`
+	Commit       string  `json:"commit,omitempty"`
+	TotalStmts   int     `json:"totalStmts"`
+	CoveredStmts int     `json:"coveredStmts"`
+	CoveragePct  float64 `json:"coveragePct"`
+}
+
+// page holds the data of the index page
+type page struct {
+	Files     []*coverage.FileMetrics
+	MetaJSON  template.JS
+	TreeJSON  template.JS
+	TrendJSON template.JS
+	Module    string
+	Modules   []moduleSummary
+	Patch     *coverage.PatchMetrics
+	Excluded  []exclude.File
+	Inline    *inline
+}
+
+// inline holds the assets and file pages inlined into a single-file report
+type inline struct {
+	CSS       template.CSS
+	JS        template.JS
+	PagesJSON template.JS
+}
+
+// moduleSummary holds the statement coverage of a single module
+type moduleSummary struct {
+	Path string
+	Dir  string
+	coverage.TotalMetrics
+}
+
+// Generate creates the index page, patch is optional and only given for
+// reports against a diff base. Reports spanning several modules list the
+// coverage per module, excluded files are listed in a collapsed section.
+// A trend chart is shown for a history of at least two entries.
+func Generate(files []*coverage.FileMetrics, excluded []exclude.File, outDir string, modules module.Modules, patch *coverage.PatchMetrics, trend []history.Entry) error {
+	data, err := newPage(files, excluded, modules, patch, trend)
+	if err != nil {
+		return err
+	}
+
+	return writeHTMLFile(outDir, data)
+}
+
+// GenerateSingle creates an index page like Generate with the stylesheets,
+// scripts and file pages inlined, so the page works offline and from any
+// location. The file page contents are keyed by local path, embedded gzip
+// compressed and rendered in place when opened.
+func GenerateSingle(files []*coverage.FileMetrics, excluded []exclude.File, outDir string, modules module.Modules, patch *coverage.PatchMetrics, trend []history.Entry, fragments map[string][]byte) error {
+	data, err := newPage(files, excluded, modules, patch, trend)
+	if err != nil {
+		return err
+	}
+
+	pagesJSON, err := compressPages(fragments)
+	if err != nil {
+		return err
+	}
+
+	// The index styles follow the file page styles to take precedence
+	// for shared selectors like .content
+	data.Inline = &inline{
+		CSS:       template.CSS(base.CSS + "\n\n" + file.CSS() + "\n\n" + indexCSS + "\n\n" + singleCSS),
+		JS:        template.JS(indexJS + "\n\n" + singleJS),
+		PagesJSON: template.JS(pagesJSON),
 	}
 
+	return writeHTMLFile(outDir, data)
+}
+
+// newPage returns the data of the index page
+func newPage(files []*coverage.FileMetrics, excluded []exclude.File, modules module.Modules, patch *coverage.PatchMetrics, trend []history.Entry) (*page, error) {
 	fileTree := tree.Build(files)
 
 	metaJSON, err := json.Marshal(files)
 	if err != nil {
-		return fmt.Errorf("failed to marshal metadata to JSON: %w", err)
+		return nil, fmt.Errorf("failed to marshal metadata to JSON: %w", err)
 	}
 
 	treeJSON, err := json.Marshal(fileTree)
 	if err != nil {
-		return fmt.Errorf("failed to marshal file tree to JSON: %w", err)
+		return nil, fmt.Errorf("failed to marshal file tree to JSON: %w", err)
 	}
 
-	data := struct {
-		Files    []*coverage.FileMetrics
-		MetaJSON template.JS
-		TreeJSON template.JS
-		Module   string
-	}{
-		Files:    files,
-		MetaJSON: template.JS(metaJSON),
-		TreeJSON: template.JS(treeJSON),
-		Module:   module,
+	var trendJSON []byte
+	if len(trend) > 1 {
+		trendJSON, err = json.Marshal(trendPoints(trend))
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal trend to JSON: %w", err)
+		}
 	}
 
-	return writeHTMLFile(outDir, data)
+	return &page{
+		Files:     files,
+		MetaJSON:  template.JS(metaJSON),
+		TreeJSON:  template.JS(treeJSON),
+		TrendJSON: template.JS(trendJSON),
+		Module:    modules.Main().Path,
+		Modules:   summarizeModules(files, modules),
+		Patch:     patch,
+		Excluded:  excluded,
+	}, nil
+}
+
+// compressPages returns the JSON object of the gzip compressed and base64
+// encoded file page contents
+func compressPages(fragments map[string][]byte) ([]byte, error) {
+	pages := make(map[string]string, len(fragments))
+	for localPath, fragment := range fragments {
+		var buf bytes.Buffer
+		zw, err := gzip.NewWriterLevel(&buf, gzip.BestCompression)
+		if err != nil {
+			return nil, fmt.Errorf("failed to compress file page: %w", err)
+		}
+		if _, err := zw.Write(fragment); err != nil {
+			return nil, fmt.Errorf("failed to compress file page %s: %w", localPath, err)
+		}
+		if err := zw.Close(); err != nil {
+			return nil, fmt.Errorf("failed to compress file page %s: %w", localPath, err)
+		}
+		pages[localPath] = base64.StdEncoding.EncodeToString(buf.Bytes())
+	}
+
+	pagesJSON, err := json.Marshal(pages)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal file pages to JSON: %w", err)
+	}
+
+	return pagesJSON, nil
+}
+
+// trendPoints returns the totals of the history entries
+func trendPoints(trend []history.Entry) []trendPoint {
+	points := make([]trendPoint, 0, len(trend))
+	for _, e := range trend {
+		points = append(points, trendPoint{
+			Timestamp:    e.Timestamp.Format(time.RFC3339),
+			Commit:       e.Commit,
+			TotalStmts:   e.Totals.TotalStmts,
+			CoveredStmts: e.Totals.CoveredStmts,
+			CoveragePct:  e.Totals.CoveragePct,
+		})
+	}
+
+	return points
+}
+
+// summarizeModules returns the coverage of every module holding files, or
+// nil for reports of a single module
+func summarizeModules(files []*coverage.FileMetrics, modules module.Modules) []moduleSummary {
+	if len(modules) < 2 {
+		return nil
+	}
+
+	var summaries []moduleSummary
+	for _, mod := range modules {
+		var members []*coverage.FileMetrics
+		for _, f := range files {
+			if f.Module == mod.Path {
+				members = append(members, f)
+			}
+		}
+		if len(members) == 0 {
+			continue
+		}
+
+		summaries = append(summaries, moduleSummary{mod.Path, mod.Dir, *coverage.Statistics(members)})
+	}
+
+	return summaries
 }
 
 // Assets writes the css and javascript files to the output directory
@@ -76,7 +234,11 @@ func Assets(outDir string) error {
 }
 
 // writeHTMLFile writes the index.html file to the output directory
-func writeHTMLFile(outDir string, data any) error {
+func writeHTMLFile(outDir string, data *page) error {
+	if err := os.MkdirAll(outDir, 0o755); err != nil {
+		return fmt.Errorf("failed to create output directory: %w", err)
+	}
+
 	tpl, err := template.New("base").Parse(base.HTML)
 	if err != nil {
 		return err
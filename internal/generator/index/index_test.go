@@ -5,11 +5,21 @@ Licensed under the MIT license, see LICENSE in the project root for details.
 package index
 
 import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"encoding/json"
+	"io"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/tschaefer/cover-ui/internal/coverage"
+	"github.com/tschaefer/cover-ui/internal/exclude"
+	"github.com/tschaefer/cover-ui/internal/history"
+	"github.com/tschaefer/cover-ui/internal/module"
 )
 
 func TestAssets(t *testing.T) {
@@ -39,9 +49,9 @@ func TestGenerate(t *testing.T) {
 		{FileName: "file1.go"},
 		{FileName: "file2.go"},
 	}
-	module := "github.com/example/project"
+	modules := module.Modules{{Path: "github.com/example/project", Dir: "."}}
 
-	err := Generate(files, outDir, module)
+	err := Generate(files, nil, outDir, modules, nil, nil)
 	if err != nil {
 		t.Fatalf("GenerateIndex() error = %v", err)
 	}
@@ -52,3 +62,156 @@ func TestGenerate(t *testing.T) {
 		t.Errorf("Expected index.html file to exist at %s", indexPath)
 	}
 }
+
+func TestGenerateExcluded(t *testing.T) {
+	outDir := t.TempDir()
+
+	files := []*coverage.FileMetrics{{FileName: "file1.go"}}
+	excluded := []exclude.File{
+		{LocalPath: "api/api.pb.go", Reason: exclude.ReasonPattern, Pattern: "*.pb.go"},
+		{LocalPath: "gen/types.go", Reason: exclude.ReasonGenerated},
+	}
+	modules := module.Modules{{Path: "github.com/example/project", Dir: "."}}
+
+	if err := Generate(files, excluded, outDir, modules, nil, nil); err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+
+	content, err := os.ReadFile(filepath.Join(outDir, "index.html"))
+	if err != nil {
+		t.Fatalf("Failed to read index.html: %v", err)
+	}
+	for _, want := range []string{"Excluded files (2)", "api/api.pb.go", "matches *.pb.go", "generated code"} {
+		if !strings.Contains(string(content), want) {
+			t.Errorf("Expected index.html to contain %q", want)
+		}
+	}
+}
+
+func TestGenerateTrend(t *testing.T) {
+	outDir := t.TempDir()
+
+	files := []*coverage.FileMetrics{{FileName: "file1.go"}}
+	modules := module.Modules{{Path: "github.com/example/project", Dir: "."}}
+	trend := []history.Entry{
+		{Timestamp: time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC), Commit: "abc", Totals: coverage.TotalMetrics{CoveragePct: 50}},
+		{Timestamp: time.Date(2026, 5, 2, 12, 0, 0, 0, time.UTC), Totals: coverage.TotalMetrics{CoveragePct: 60}},
+	}
+
+	for _, tt := range []struct {
+		trend []history.Entry
+		chart bool
+	}{
+		{trend[:1], false},
+		{trend, true},
+	} {
+		if err := Generate(files, nil, outDir, modules, nil, tt.trend); err != nil {
+			t.Fatalf("Generate() error = %v", err)
+		}
+
+		content, err := os.ReadFile(filepath.Join(outDir, "index.html"))
+		if err != nil {
+			t.Fatalf("Failed to read index.html: %v", err)
+		}
+		if got := strings.Contains(string(content), `id="trend"`); got != tt.chart {
+			t.Errorf("Expected trend chart %v for %d entries, got %v", tt.chart, len(tt.trend), got)
+		}
+		if tt.chart && !strings.Contains(string(content), `"timestamp":"2026-05-02T12:00:00Z"`) {
+			t.Errorf("Expected trend data in index.html")
+		}
+	}
+}
+
+func TestSummarizeModules(t *testing.T) {
+	files := []*coverage.FileMetrics{
+		{FileName: "example.com/api/a.go", Module: "example.com/api", TotalStmts: 4, CoveredStmts: 1},
+		{FileName: "example.com/api/b.go", Module: "example.com/api", TotalStmts: 4, CoveredStmts: 3},
+		{FileName: "example.com/svc/c.go", Module: "example.com/svc", TotalStmts: 2, CoveredStmts: 2},
+	}
+	modules := module.Modules{
+		{Path: "example.com/api", Dir: "api"},
+		{Path: "example.com/empty", Dir: "empty"},
+		{Path: "example.com/svc", Dir: "svc"},
+	}
+
+	summaries := summarizeModules(files, modules)
+	if len(summaries) != 2 {
+		t.Fatalf("Expected 2 module summaries, got %d", len(summaries))
+	}
+	if summaries[0].Path != "example.com/api" || summaries[0].TotalFiles != 2 || summaries[0].CoveragePct != 50 {
+		t.Errorf("Unexpected first summary %+v", summaries[0])
+	}
+	if summaries[1].Path != "example.com/svc" || summaries[1].Dir != "svc" || summaries[1].CoveragePct != 100 {
+		t.Errorf("Unexpected second summary %+v", summaries[1])
+	}
+
+	if summaries := summarizeModules(files, modules[:1]); summaries != nil {
+		t.Errorf("Expected no summaries for a single module, got %+v", summaries)
+	}
+}
+
+func TestGenerateSingle(t *testing.T) {
+	outDir := t.TempDir()
+
+	files := []*coverage.FileMetrics{{FileName: "example.com/project/main.go", LocalPath: "main.go"}}
+	modules := module.Modules{{Path: "github.com/example/project", Dir: "."}}
+	fragments := map[string][]byte{"main.go": []byte(`<div class="line" id="line-1">package main</div>`)}
+
+	if err := GenerateSingle(files, nil, outDir, modules, nil, nil, fragments); err != nil {
+		t.Fatalf("GenerateSingle() error = %v", err)
+	}
+
+	content, err := os.ReadFile(filepath.Join(outDir, "index.html"))
+	if err != nil {
+		t.Fatalf("Failed to read index.html: %v", err)
+	}
+	page := string(content)
+
+	for _, want := range []string{"<style>", "const filePages = {", `id="file-view"`, `id="file-nav"`, "const SinglePage"} {
+		if !strings.Contains(page, want) {
+			t.Errorf("Expected index.html to contain %q", want)
+		}
+	}
+	for _, unwanted := range []string{`href="style.css"`, `src="script.js"`, "line-1"} {
+		if strings.Contains(page, unwanted) {
+			t.Errorf("Expected index.html not to contain %q", unwanted)
+		}
+	}
+
+	entries, err := os.ReadDir(outDir)
+	if err != nil {
+		t.Fatalf("Failed to read output directory: %v", err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("Expected index.html only, got %d files", len(entries))
+	}
+}
+
+func TestCompressPages(t *testing.T) {
+	pagesJSON, err := compressPages(map[string][]byte{"main.go": []byte("<div>main</div>")})
+	if err != nil {
+		t.Fatalf("compressPages() error = %v", err)
+	}
+
+	var pages map[string]string
+	if err := json.Unmarshal(pagesJSON, &pages); err != nil {
+		t.Fatalf("Failed to parse pages: %v", err)
+	}
+
+	data, err := base64.StdEncoding.DecodeString(pages["main.go"])
+	if err != nil {
+		t.Fatalf("Failed to decode page: %v", err)
+	}
+	zr, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("Failed to decompress page: %v", err)
+	}
+	fragment, err := io.ReadAll(zr)
+	if err != nil {
+		t.Fatalf("Failed to decompress page: %v", err)
+	}
+
+	if string(fragment) != "<div>main</div>" {
+		t.Errorf("Expected fragment <div>main</div>, got %s", fragment)
+	}
+}
@@ -0,0 +1,126 @@
+/*
+Copyright (c) Tobias Schäfer. All rights reserved.
+Licensed under the MIT license, see LICENSE in the project root for details.
+*/
+package lcov
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/tschaefer/cover-ui/internal/coverage"
+)
+
+// Generate writes the LCOV tracefile to the output directory
+func Generate(files []*coverage.FileMetrics, outDir string, srcRoot string) error {
+	if err := os.MkdirAll(outDir, 0o755); err != nil {
+		return fmt.Errorf("failed to create output directory: %w", err)
+	}
+
+	source, err := filepath.Abs(srcRoot)
+	if err != nil {
+		return fmt.Errorf("failed to resolve source root: %w", err)
+	}
+
+	outPath := filepath.Join(outDir, "lcov.info")
+	w, err := os.Create(outPath)
+	if err != nil {
+		return fmt.Errorf("failed to create lcov tracefile: %w", err)
+	}
+	defer func() {
+		_ = w.Close()
+	}()
+
+	if err := Write(w, files, source); err != nil {
+		return fmt.Errorf("failed to write lcov tracefile: %w", err)
+	}
+
+	return nil
+}
+
+// Write emits one LCOV record per file. Source file paths are the local
+// paths joined to the source root. Line hits are taken from the hit counts
+// of count and atomic profiles, otherwise every covered or partial line
+// counts as one hit.
+func Write(w io.Writer, files []*coverage.FileMetrics, srcRoot string) error {
+	bw := bufio.NewWriter(w)
+
+	for _, f := range files {
+		writeRecord(bw, f, srcRoot)
+	}
+
+	return bw.Flush()
+}
+
+// writeRecord writes the record of a single source file
+func writeRecord(w *bufio.Writer, f *coverage.FileMetrics, srcRoot string) {
+	_, _ = fmt.Fprintln(w, "TN:")
+	_, _ = fmt.Fprintf(w, "SF:%s\n", filepath.Join(srcRoot, f.LocalPath))
+
+	functionsHit := 0
+	for _, fn := range f.Functions {
+		_, _ = fmt.Fprintf(w, "FN:%d,%s\n", fn.StartLine, fn.QualifiedName())
+	}
+	for _, fn := range f.Functions {
+		hits := functionHits(f, fn)
+		if hits > 0 {
+			functionsHit++
+		}
+		_, _ = fmt.Fprintf(w, "FNDA:%d,%s\n", hits, fn.QualifiedName())
+	}
+	if len(f.Functions) > 0 {
+		_, _ = fmt.Fprintf(w, "FNF:%d\n", len(f.Functions))
+		_, _ = fmt.Fprintf(w, "FNH:%d\n", functionsHit)
+	}
+
+	linesFound := 0
+	linesHit := 0
+	for ln := 1; ln < len(f.PerLineStatus); ln++ {
+		hits, ok := lineHits(f, ln)
+		if !ok {
+			continue
+		}
+
+		linesFound++
+		if hits > 0 {
+			linesHit++
+		}
+		_, _ = fmt.Fprintf(w, "DA:%d,%d\n", ln, hits)
+	}
+
+	_, _ = fmt.Fprintf(w, "LF:%d\n", linesFound)
+	_, _ = fmt.Fprintf(w, "LH:%d\n", linesHit)
+	_, _ = fmt.Fprintln(w, "end_of_record")
+}
+
+// lineHits returns the hit count of a tracked source line
+func lineHits(f *coverage.FileMetrics, ln int) (int, bool) {
+	if ln < 1 || ln >= len(f.PerLineStatus) || f.PerLineStatus[ln] < 0 {
+		return 0, false
+	}
+
+	if ln < len(f.PerLineHits) && f.PerLineHits[ln] >= 0 {
+		return f.PerLineHits[ln], true
+	}
+
+	if coverage.LineStatus(f.PerLineStatus[ln]) == coverage.Missed {
+		return 0, true
+	}
+
+	return 1, true
+}
+
+// functionHits returns the hit count of the first tracked line of a
+// function, which is executed on every call
+func functionHits(f *coverage.FileMetrics, fn coverage.FunctionMetrics) int {
+	for ln := fn.StartLine; ln <= fn.EndLine; ln++ {
+		if hits, ok := lineHits(f, ln); ok {
+			return hits
+		}
+	}
+
+	return 0
+}
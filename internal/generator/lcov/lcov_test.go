@@ -0,0 +1,79 @@
+/*
+Copyright (c) Tobias Schäfer. All rights reserved.
+Licensed under the MIT license, see LICENSE in the project root for details.
+*/
+package lcov
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/tschaefer/cover-ui/internal/coverage"
+)
+
+func TestWrite(t *testing.T) {
+	files := []*coverage.FileMetrics{
+		{
+			LocalPath:     "main.go",
+			PerLineStatus: []int{-1, -1, int(coverage.Covered), int(coverage.Missed)},
+			Functions: []coverage.FunctionMetrics{
+				{Name: "main", StartLine: 1, EndLine: 4},
+			},
+		},
+		{
+			LocalPath:     "pkg/utils.go",
+			PerLineStatus: []int{-1, int(coverage.Partial), int(coverage.Missed)},
+			PerLineHits:   []int{-1, 7, 0},
+			Functions: []coverage.FunctionMetrics{
+				{Name: "Start", Receiver: "*Server", StartLine: 1, EndLine: 2},
+			},
+		},
+	}
+
+	var sb strings.Builder
+	if err := Write(&sb, files, "/src"); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+
+	want := `TN:
+SF:/src/main.go
+FN:1,main
+FNDA:1,main
+FNF:1
+FNH:1
+DA:2,1
+DA:3,0
+LF:2
+LH:1
+end_of_record
+TN:
+SF:/src/pkg/utils.go
+FN:1,(*Server).Start
+FNDA:7,(*Server).Start
+FNF:1
+FNH:1
+DA:1,7
+DA:2,0
+LF:2
+LH:1
+end_of_record
+`
+	if sb.String() != want {
+		t.Errorf("Unexpected tracefile:\n%s", sb.String())
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	outDir := t.TempDir()
+
+	files := []*coverage.FileMetrics{{LocalPath: "main.go"}}
+	if err := Generate(files, outDir, "."); err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+
+	if _, err := os.Stat(filepath.Join(outDir, "lcov.info")); os.IsNotExist(err) {
+		t.Errorf("Expected lcov.info file to exist")
+	}
+}
@@ -0,0 +1,282 @@
+/*
+Copyright (c) Tobias Schäfer. All rights reserved.
+Licensed under the MIT license, see LICENSE in the project root for details.
+*/
+package markdown
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/tschaefer/cover-ui/internal/coverage"
+)
+
+// FileName is the name of the summary within the output directory
+const FileName = "summary.md"
+
+// Options configure the summary
+type Options struct {
+	// Base holds the files of an earlier report to compute deltas against,
+	// deltas are left out if nil
+	Base []*coverage.FileMetrics
+	// MaxFiles limits the list of lowest covered files
+	MaxFiles int
+	// MaxBytes limits the size of the summary, e.g. to the size limit of
+	// pull request comments, 0 disables the limit
+	MaxBytes int
+}
+
+// DefaultOptions returns options fitting into GitHub and GitLab comments
+func DefaultOptions() Options {
+	return Options{MaxFiles: 10, MaxBytes: 60000}
+}
+
+// table is a Markdown table of a summary section
+type table struct {
+	title   string
+	header  []string
+	rows    [][]string
+	noun    string
+	omitted int
+}
+
+// pkg holds the statement coverage of the files directly within a
+// directory
+type pkg struct {
+	path string
+	*coverage.TotalMetrics
+}
+
+// Generate writes the Markdown summary to the output directory
+func Generate(files []*coverage.FileMetrics, outDir string, opts Options) error {
+	if err := os.MkdirAll(outDir, 0o755); err != nil {
+		return fmt.Errorf("failed to create output directory: %w", err)
+	}
+
+	outPath := filepath.Join(outDir, FileName)
+	w, err := os.Create(outPath)
+	if err != nil {
+		return fmt.Errorf("failed to create Markdown summary: %w", err)
+	}
+	defer func() {
+		_ = w.Close()
+	}()
+
+	if err := Write(w, files, opts); err != nil {
+		return fmt.Errorf("failed to write Markdown summary: %w", err)
+	}
+
+	return nil
+}
+
+// Write emits the totals, the lowest covered files and a per package
+// table, each with the delta against the base if given. Rows are dropped
+// from the end of the tables, packages first, until the summary fits into
+// the size limit.
+func Write(w io.Writer, files []*coverage.FileMetrics, opts Options) error {
+	head := renderTotals(files, opts.Base)
+	tables := []*table{lowestFiles(files, opts), packageTable(files, opts.Base)}
+
+	summary := render(head, tables)
+	for i := len(tables) - 1; i >= 0 && opts.MaxBytes > 0; i-- {
+		t := tables[i]
+		for len(t.rows) > 0 && len(summary) > opts.MaxBytes {
+			t.rows = t.rows[:len(t.rows)-1]
+			t.omitted++
+			summary = render(head, tables)
+		}
+	}
+
+	_, err := io.WriteString(w, summary)
+	return err
+}
+
+// render joins the totals and the tables
+func render(head string, tables []*table) string {
+	var b strings.Builder
+	b.WriteString(head)
+
+	for _, t := range tables {
+		if len(t.rows) == 0 && t.omitted == 0 {
+			continue
+		}
+
+		fmt.Fprintf(&b, "\n### %s\n\n", t.title)
+		if len(t.rows) > 0 {
+			writeRow(&b, t.header)
+			align := make([]string, len(t.header))
+			for i := range align {
+				align[i] = "---:"
+			}
+			align[0] = "---"
+			writeRow(&b, align)
+			for _, row := range t.rows {
+				writeRow(&b, row)
+			}
+		}
+		if t.omitted > 0 {
+			if len(t.rows) > 0 {
+				b.WriteString("\n")
+			}
+			fmt.Fprintf(&b, "_%d more %s not shown._\n", t.omitted, t.noun)
+		}
+	}
+
+	return b.String()
+}
+
+// writeRow writes a table row
+func writeRow(b *strings.Builder, cells []string) {
+	b.WriteString("| " + strings.Join(cells, " | ") + " |\n")
+}
+
+// renderTotals returns the heading and the total coverage
+func renderTotals(files []*coverage.FileMetrics, base []*coverage.FileMetrics) string {
+	total := coverage.Statistics(files)
+
+	var b strings.Builder
+	b.WriteString("## Coverage report\n\n")
+	fmt.Fprintf(&b, "**%.2f%%** of statements covered (%d of %d) in %d files",
+		total.CoveragePct, total.CoveredStmts, total.TotalStmts, total.TotalFiles)
+	if base != nil {
+		basePct := coverage.Statistics(base).CoveragePct
+		fmt.Fprintf(&b, ", %s against base (%.2f%%)", formatDelta(total.CoveragePct-basePct), basePct)
+	}
+	b.WriteString(".\n")
+
+	return b.String()
+}
+
+// lowestFiles returns the table of the files with the lowest coverage, not
+// fully covered files with statements only
+func lowestFiles(files []*coverage.FileMetrics, opts Options) *table {
+	var candidates []*coverage.FileMetrics
+	for _, f := range files {
+		if f.TotalStmts > 0 && f.CoveredStmts < f.TotalStmts {
+			candidates = append(candidates, f)
+		}
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		a, b := candidates[i], candidates[j]
+		if a.CoveragePct != b.CoveragePct {
+			return a.CoveragePct < b.CoveragePct
+		}
+		if missedA, missedB := a.TotalStmts-a.CoveredStmts, b.TotalStmts-b.CoveredStmts; missedA != missedB {
+			return missedA > missedB
+		}
+		return a.LocalPath < b.LocalPath
+	})
+
+	t := &table{
+		title:  "Lowest covered files",
+		header: []string{"File", "Statements", "Missed", "Coverage"},
+		noun:   "files",
+	}
+	if opts.MaxFiles > 0 && len(candidates) > opts.MaxFiles {
+		t.omitted = len(candidates) - opts.MaxFiles
+		candidates = candidates[:opts.MaxFiles]
+	}
+
+	var baseFiles map[string]float64
+	if opts.Base != nil {
+		t.header = append(t.header, "Δ")
+		baseFiles = make(map[string]float64, len(opts.Base))
+		for _, f := range opts.Base {
+			baseFiles[f.LocalPath] = f.CoveragePct
+		}
+	}
+
+	for _, f := range candidates {
+		row := []string{
+			code(f.LocalPath),
+			fmt.Sprint(f.TotalStmts),
+			fmt.Sprint(f.TotalStmts - f.CoveredStmts),
+			fmt.Sprintf("%.2f%%", f.CoveragePct),
+		}
+		if baseFiles != nil {
+			row = append(row, delta(f.CoveragePct, baseFiles, f.LocalPath))
+		}
+		t.rows = append(t.rows, row)
+	}
+
+	return t
+}
+
+// packageTable returns the table of the packages sorted by directory
+func packageTable(files []*coverage.FileMetrics, base []*coverage.FileMetrics) *table {
+	t := &table{
+		title:  "Packages",
+		header: []string{"Package", "Files", "Statements", "Coverage"},
+		noun:   "packages",
+	}
+
+	var basePkgs map[string]float64
+	if base != nil {
+		t.header = append(t.header, "Δ")
+		basePkgs = make(map[string]float64)
+		for _, p := range packages(base) {
+			basePkgs[p.path] = p.CoveragePct
+		}
+	}
+
+	for _, p := range packages(files) {
+		row := []string{
+			code(p.path),
+			fmt.Sprint(p.TotalFiles),
+			fmt.Sprint(p.TotalStmts),
+			fmt.Sprintf("%.2f%%", p.CoveragePct),
+		}
+		if basePkgs != nil {
+			row = append(row, delta(p.CoveragePct, basePkgs, p.path))
+		}
+		t.rows = append(t.rows, row)
+	}
+
+	return t
+}
+
+// packages returns the coverage of the files directly within each
+// directory, sorted by directory
+func packages(files []*coverage.FileMetrics) []pkg {
+	var pkgs []pkg
+	for _, p := range coverage.Packages(files) {
+		pkgs = append(pkgs, pkg{p.Dir, coverage.Statistics(p.Files)})
+	}
+
+	return pkgs
+}
+
+// delta returns the formatted coverage delta of a file or package against
+// its base coverage, missing from the base for new ones
+func delta(pct float64, base map[string]float64, key string) string {
+	basePct, ok := base[key]
+	if !ok {
+		return "new"
+	}
+
+	return formatDelta(pct - basePct)
+}
+
+// formatDelta returns a coverage delta with an up or down arrow, deltas
+// rounding to zero have none
+func formatDelta(d float64) string {
+	switch {
+	case math.Round(d*100) > 0:
+		return fmt.Sprintf("↑ +%.2f%%", d)
+	case math.Round(d*100) < 0:
+		return fmt.Sprintf("↓ %.2f%%", d)
+	default:
+		return "0.00%"
+	}
+}
+
+// code returns a path as inline code, escaping the table cell separator
+func code(path string) string {
+	return "`" + strings.ReplaceAll(path, "|", `\|`) + "`"
+}
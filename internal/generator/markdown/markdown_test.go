@@ -0,0 +1,147 @@
+/*
+Copyright (c) Tobias Schäfer. All rights reserved.
+Licensed under the MIT license, see LICENSE in the project root for details.
+*/
+package markdown
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/tschaefer/cover-ui/internal/coverage"
+)
+
+func metrics(localPath string, total, covered int) *coverage.FileMetrics {
+	pct := 0.0
+	if total > 0 {
+		pct = float64(covered) / float64(total) * 100
+	}
+
+	return &coverage.FileMetrics{
+		FileName:     "example.com/mod/" + localPath,
+		LocalPath:    localPath,
+		TotalStmts:   total,
+		CoveredStmts: covered,
+		CoveragePct:  pct,
+	}
+}
+
+func TestWrite(t *testing.T) {
+	files := []*coverage.FileMetrics{
+		metrics("main.go", 4, 4),
+		metrics("pkg/a.go", 4, 1),
+		metrics("pkg/b.go", 4, 2),
+	}
+
+	var sb strings.Builder
+	if err := Write(&sb, files, DefaultOptions()); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+
+	want := "## Coverage report\n" +
+		"\n" +
+		"**58.33%** of statements covered (7 of 12) in 3 files.\n" +
+		"\n" +
+		"### Lowest covered files\n" +
+		"\n" +
+		"| File | Statements | Missed | Coverage |\n" +
+		"| --- | ---: | ---: | ---: |\n" +
+		"| `pkg/a.go` | 4 | 3 | 25.00% |\n" +
+		"| `pkg/b.go` | 4 | 2 | 50.00% |\n" +
+		"\n" +
+		"### Packages\n" +
+		"\n" +
+		"| Package | Files | Statements | Coverage |\n" +
+		"| --- | ---: | ---: | ---: |\n" +
+		"| `.` | 1 | 4 | 100.00% |\n" +
+		"| `pkg` | 2 | 8 | 37.50% |\n"
+
+	if sb.String() != want {
+		t.Errorf("Expected summary:\n%s\ngot:\n%s", want, sb.String())
+	}
+}
+
+func TestWriteBase(t *testing.T) {
+	files := []*coverage.FileMetrics{
+		metrics("pkg/a.go", 4, 1),
+		metrics("pkg/b.go", 4, 2),
+		metrics("new/c.go", 2, 0),
+	}
+	base := []*coverage.FileMetrics{
+		metrics("pkg/a.go", 4, 2),
+		metrics("pkg/b.go", 4, 2),
+	}
+
+	opts := DefaultOptions()
+	opts.Base = base
+
+	var sb strings.Builder
+	if err := Write(&sb, files, opts); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+
+	for _, want := range []string{
+		"**30.00%** of statements covered (3 of 10) in 3 files, ↓ -20.00% against base (50.00%).",
+		"| File | Statements | Missed | Coverage | Δ |",
+		"| `pkg/a.go` | 4 | 3 | 25.00% | ↓ -25.00% |",
+		"| `pkg/b.go` | 4 | 2 | 50.00% | 0.00% |",
+		"| `new/c.go` | 2 | 2 | 0.00% | new |",
+		"| `pkg` | 2 | 8 | 37.50% | ↓ -12.50% |",
+		"| `new` | 1 | 2 | 0.00% | new |",
+	} {
+		if !strings.Contains(sb.String(), want) {
+			t.Errorf("Expected summary to contain %q, got:\n%s", want, sb.String())
+		}
+	}
+}
+
+func TestWriteLimits(t *testing.T) {
+	var files []*coverage.FileMetrics
+	for i := range 50 {
+		files = append(files, metrics(fmt.Sprintf("pkg%02d/file.go", i), 10, i%10))
+	}
+
+	opts := Options{MaxFiles: 5}
+
+	var sb strings.Builder
+	if err := Write(&sb, files, opts); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	if !strings.Contains(sb.String(), "_45 more files not shown._") {
+		t.Errorf("Expected file list limited to 5 files, got:\n%s", sb.String())
+	}
+	full := sb.Len()
+
+	opts.MaxBytes = full - 200
+	sb.Reset()
+	if err := Write(&sb, files, opts); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	if sb.Len() > opts.MaxBytes {
+		t.Errorf("Expected at most %d bytes, got %d", opts.MaxBytes, sb.Len())
+	}
+	if !strings.Contains(sb.String(), "more packages not shown._") {
+		t.Errorf("Expected package table to be cut, got:\n%s", sb.String())
+	}
+	if !strings.Contains(sb.String(), "| `pkg00/file.go` |") {
+		t.Errorf("Expected lowest covered files to be kept, got:\n%s", sb.String())
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	outDir := t.TempDir()
+	if err := Generate([]*coverage.FileMetrics{metrics("main.go", 2, 1)}, outDir, DefaultOptions()); err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+
+	content, err := os.ReadFile(filepath.Join(outDir, FileName))
+	if err != nil {
+		t.Fatalf("Expected summary file, got %v", err)
+	}
+	if !strings.Contains(string(content), "**50.00%**") {
+		t.Errorf("Expected total coverage in summary, got:\n%s", content)
+	}
+}
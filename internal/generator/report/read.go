@@ -0,0 +1,113 @@
+/*
+Copyright (c) Tobias Schäfer. All rights reserved.
+Licensed under the MIT license, see LICENSE in the project root for details.
+*/
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/tschaefer/cover-ui/internal/coverage"
+)
+
+// lineStatuses maps the line status names of the report to line statuses
+var lineStatuses = map[string]coverage.LineStatus{
+	"missed":  coverage.Missed,
+	"partial": coverage.Partial,
+	"covered": coverage.Covered,
+}
+
+// Read reads a JSON report, reports of a newer schema version are rejected
+func Read(path string) (*Report, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read json report: %w", err)
+	}
+
+	var r Report
+	if err := json.Unmarshal(content, &r); err != nil {
+		return nil, fmt.Errorf("failed to parse json report %s: %w", path, err)
+	}
+	if r.SchemaVersion < 1 || r.SchemaVersion > SchemaVersion {
+		return nil, fmt.Errorf("failed to parse json report %s: unsupported schema version %d", path, r.SchemaVersion)
+	}
+
+	return &r, nil
+}
+
+// FileMetrics converts the files of the report back into file metrics
+func (r *Report) FileMetrics() []*coverage.FileMetrics {
+	files := make([]*coverage.FileMetrics, 0, len(r.Files))
+	for _, f := range r.Files {
+		files = append(files, fileMetrics(f, r.Mode))
+	}
+
+	return files
+}
+
+// fileMetrics converts a file of the report into file metrics
+func fileMetrics(f File, mode string) *coverage.FileMetrics {
+	lineCount := 0
+	hasHits := false
+	for _, line := range f.Lines {
+		lineCount = max(lineCount, line.Number)
+		hasHits = hasHits || line.Hits != nil
+	}
+	for _, ln := range f.IgnoredLines {
+		lineCount = max(lineCount, ln)
+	}
+
+	perLineStatus := make([]int, lineCount+1)
+	perLineHits := make([]int, lineCount+1)
+	for ln := range perLineStatus {
+		perLineStatus[ln] = -1
+		perLineHits[ln] = -1
+	}
+	for _, line := range f.Lines {
+		status, ok := lineStatuses[line.Status]
+		if !ok || line.Number < 1 {
+			continue
+		}
+		perLineStatus[line.Number] = int(status)
+		if line.Hits != nil {
+			perLineHits[line.Number] = *line.Hits
+		}
+	}
+	if !hasHits {
+		perLineHits = nil
+	}
+
+	functions := make([]coverage.FunctionMetrics, 0, len(f.Functions))
+	for _, fn := range f.Functions {
+		functions = append(functions, coverage.FunctionMetrics{
+			Name:         fn.Name,
+			Receiver:     fn.Receiver,
+			StartLine:    fn.StartLine,
+			EndLine:      fn.EndLine,
+			TotalStmts:   fn.Statements,
+			CoveredStmts: fn.CoveredStatements,
+			CoveragePct:  fn.CoveragePct,
+		})
+	}
+
+	return &coverage.FileMetrics{
+		FileName:      f.ImportPath,
+		LocalPath:     f.Path,
+		Package:       f.Package,
+		TrackedLines:  f.Totals.TrackedLines,
+		CoveredLines:  f.Totals.CoveredLines,
+		PartialLines:  f.Totals.PartialLines,
+		MissedLines:   f.Totals.MissedLines,
+		CoveragePct:   f.Totals.CoveragePct,
+		TotalStmts:    f.Totals.Statements,
+		CoveredStmts:  f.Totals.CoveredStatements,
+		PerLineStatus: perLineStatus,
+		Mode:          mode,
+		PerLineHits:   perLineHits,
+		IgnoredLines:  f.IgnoredLines,
+		ChangedLines:  f.ChangedLines,
+		Functions:     functions,
+	}
+}
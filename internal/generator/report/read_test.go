@@ -0,0 +1,65 @@
+/*
+Copyright (c) Tobias Schäfer. All rights reserved.
+Licensed under the MIT license, see LICENSE in the project root for details.
+*/
+package report
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestRead(t *testing.T) {
+	outDir := t.TempDir()
+	if err := Generate(testFiles(), nil, outDir, testModules, nil); err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+
+	r, err := Read(filepath.Join(outDir, FileName))
+	if err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+
+	files := r.FileMetrics()
+	if len(files) != 2 {
+		t.Fatalf("Expected 2 files, got %d", len(files))
+	}
+
+	want := testFiles()[0]
+	got := files[1]
+	if got.LocalPath != want.LocalPath || got.FileName != want.FileName || got.Mode != want.Mode {
+		t.Errorf("Unexpected file %s (%s, %s)", got.LocalPath, got.FileName, got.Mode)
+	}
+	if got.TotalStmts != want.TotalStmts || got.CoveredStmts != want.CoveredStmts || got.CoveragePct != want.CoveragePct {
+		t.Errorf("Expected statements %d of %d, got %d of %d", want.CoveredStmts, want.TotalStmts, got.CoveredStmts, got.TotalStmts)
+	}
+	if !reflect.DeepEqual(got.PerLineStatus, want.PerLineStatus) {
+		t.Errorf("Expected line statuses %v, got %v", want.PerLineStatus, got.PerLineStatus)
+	}
+	if !reflect.DeepEqual(got.PerLineHits, want.PerLineHits) {
+		t.Errorf("Expected line hits %v, got %v", want.PerLineHits, got.PerLineHits)
+	}
+	if len(got.Functions) != 1 || got.Functions[0].TotalStmts != 4 {
+		t.Errorf("Unexpected functions %+v", got.Functions)
+	}
+}
+
+func TestReadInvalid(t *testing.T) {
+	dir := t.TempDir()
+
+	for name, content := range map[string]string{
+		"invalid.json": "{",
+		"future.json":  `{"schemaVersion": 99}`,
+		"profile.json": "mode: set\n",
+	} {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatalf("Failed to write file: %v", err)
+		}
+		if _, err := Read(path); err == nil {
+			t.Errorf("Expected error reading %s, got nil", name)
+		}
+	}
+}
@@ -0,0 +1,286 @@
+/*
+Copyright (c) Tobias Schäfer. All rights reserved.
+Licensed under the MIT license, see LICENSE in the project root for details.
+*/
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/tschaefer/cover-ui/internal/coverage"
+	"github.com/tschaefer/cover-ui/internal/exclude"
+	"github.com/tschaefer/cover-ui/internal/module"
+	"github.com/tschaefer/cover-ui/internal/tree"
+	"github.com/tschaefer/cover-ui/internal/version"
+)
+
+// SchemaVersion is the version of the JSON report schema. It is increased
+// on every incompatible change; adding fields is not considered one.
+const SchemaVersion = 1
+
+// FileName is the name of the JSON report within the output directory
+const FileName = "report.json"
+
+// Report is the root object of the JSON report
+type Report struct {
+	SchemaVersion int            `json:"schemaVersion"`
+	Generator     Generator      `json:"generator"`
+	GeneratedAt   time.Time      `json:"generatedAt"`
+	Module        string         `json:"module"`
+	Mode          string         `json:"mode"`
+	Totals        Totals         `json:"totals"`
+	Patch         *Patch         `json:"patch,omitempty"`
+	Modules       []Module       `json:"modules"`
+	Packages      []Package      `json:"packages"`
+	Files         []File         `json:"files"`
+	Excluded      []exclude.File `json:"excluded"`
+}
+
+// Generator identifies the tool that created the report
+type Generator struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+	Commit  string `json:"commit"`
+}
+
+// Totals holds aggregated statement and line coverage
+type Totals struct {
+	Files             int     `json:"files"`
+	Statements        int     `json:"statements"`
+	CoveredStatements int     `json:"coveredStatements"`
+	CoveragePct       float64 `json:"coveragePct"`
+	TrackedLines      int     `json:"trackedLines"`
+	CoveredLines      int     `json:"coveredLines"`
+	PartialLines      int     `json:"partialLines"`
+	MissedLines       int     `json:"missedLines"`
+}
+
+// Patch holds the coverage of changed lines against a diff base
+type Patch struct {
+	Base         string  `json:"base"`
+	Files        int     `json:"files"`
+	ChangedLines int     `json:"changedLines"`
+	TrackedLines int     `json:"trackedLines"`
+	CoveredLines int     `json:"coveredLines"`
+	PartialLines int     `json:"partialLines"`
+	MissedLines  int     `json:"missedLines"`
+	CoveragePct  float64 `json:"coveragePct"`
+}
+
+// Module holds the totals of the files of a single module
+type Module struct {
+	Path   string `json:"path"`
+	Dir    string `json:"dir"`
+	Totals Totals `json:"totals"`
+}
+
+// Package holds the totals of the files of a single directory, not
+// including subdirectories
+type Package struct {
+	ImportPath string `json:"importPath"`
+	Dir        string `json:"dir"`
+	Totals     Totals `json:"totals"`
+}
+
+// File holds the coverage of a single source file
+type File struct {
+	Path         string     `json:"path"`
+	ImportPath   string     `json:"importPath"`
+	Package      string     `json:"package"`
+	Totals       Totals     `json:"totals"`
+	Functions    []Function `json:"functions"`
+	Lines        []Line     `json:"lines"`
+	IgnoredLines []int      `json:"ignoredLines,omitempty"`
+	ChangedLines []int      `json:"changedLines,omitempty"`
+}
+
+// Function holds the coverage of a single function or method
+type Function struct {
+	Name              string  `json:"name"`
+	Receiver          string  `json:"receiver,omitempty"`
+	StartLine         int     `json:"startLine"`
+	EndLine           int     `json:"endLine"`
+	Statements        int     `json:"statements"`
+	CoveredStatements int     `json:"coveredStatements"`
+	CoveragePct       float64 `json:"coveragePct"`
+}
+
+// Line holds the status of a tracked source line, one of "covered",
+// "partial" or "missed". Hits are only set for count and atomic profiles.
+type Line struct {
+	Number int    `json:"number"`
+	Status string `json:"status"`
+	Hits   *int   `json:"hits,omitempty"`
+}
+
+// Generate writes the JSON report to the output directory
+func Generate(files []*coverage.FileMetrics, excluded []exclude.File, outDir string, modules module.Modules, patch *coverage.PatchMetrics) error {
+	if err := os.MkdirAll(outDir, 0o755); err != nil {
+		return fmt.Errorf("failed to create output directory: %w", err)
+	}
+
+	out, err := json.MarshalIndent(Build(files, excluded, modules, patch), "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal json report: %w", err)
+	}
+
+	outPath := filepath.Join(outDir, FileName)
+	if err := os.WriteFile(outPath, append(out, '\n'), 0o644); err != nil {
+		return fmt.Errorf("failed to write json report: %w", err)
+	}
+
+	return nil
+}
+
+// Build creates the JSON report from the file metrics. The report module is
+// the main module of the source root, packages are named by the import path
+// within the module holding them. Files left out of the report are listed
+// as excluded.
+func Build(files []*coverage.FileMetrics, excluded []exclude.File, modules module.Modules, patch *coverage.PatchMetrics) *Report {
+	main := modules.Main().Path
+
+	r := &Report{
+		SchemaVersion: SchemaVersion,
+		Generator: Generator{
+			Name:    "gocover-ui",
+			Version: version.Release(),
+			Commit:  version.Commit(),
+		},
+		GeneratedAt: time.Now().UTC().Truncate(time.Second),
+		Module:      main,
+		Totals:      totals(files),
+		Modules:     []Module{},
+		Packages:    []Package{},
+		Files:       []File{},
+		Excluded:    []exclude.File{},
+	}
+
+	if len(files) > 0 {
+		r.Mode = files[0].Mode
+	}
+	r.Excluded = append(r.Excluded, excluded...)
+
+	if patch != nil {
+		r.Patch = &Patch{
+			Base:         patch.Base,
+			Files:        patch.TotalFiles,
+			ChangedLines: patch.ChangedLines,
+			TrackedLines: patch.TrackedLines,
+			CoveredLines: patch.CoveredLines,
+			PartialLines: patch.PartialLines,
+			MissedLines:  patch.MissedLines,
+			CoveragePct:  patch.CoveragePct,
+		}
+	}
+
+	for _, mod := range modules {
+		var members []*coverage.FileMetrics
+		for _, f := range files {
+			if f.Module == mod.Path {
+				members = append(members, f)
+			}
+		}
+		if len(members) > 0 {
+			r.Modules = append(r.Modules, Module{Path: mod.Path, Dir: mod.Dir, Totals: totals(members)})
+		}
+	}
+
+	var walk func(node *tree.Node)
+	walk = func(node *tree.Node) {
+		var members []*coverage.FileMetrics
+		for _, child := range node.Children {
+			if child.IsDir {
+				walk(child)
+				continue
+			}
+			members = append(members, child.File)
+		}
+
+		if len(members) == 0 {
+			return
+		}
+
+		dir := "."
+		if node.Path != "/" {
+			dir = node.Path
+		}
+		pkg := members[0].Package
+		if pkg == "" {
+			pkg = coverage.ImportPath(main, dir)
+		}
+
+		r.Packages = append(r.Packages, Package{ImportPath: pkg, Dir: dir, Totals: totals(members)})
+		for _, f := range members {
+			r.Files = append(r.Files, buildFile(f, pkg))
+		}
+	}
+	walk(tree.Build(files))
+
+	return r
+}
+
+// buildFile creates the report entry of a single file
+func buildFile(f *coverage.FileMetrics, pkg string) File {
+	file := File{
+		Path:         f.LocalPath,
+		ImportPath:   f.FileName,
+		Package:      pkg,
+		Totals:       totals([]*coverage.FileMetrics{f}),
+		Functions:    []Function{},
+		Lines:        []Line{},
+		IgnoredLines: f.IgnoredLines,
+		ChangedLines: f.ChangedLines,
+	}
+
+	for _, fn := range f.Functions {
+		file.Functions = append(file.Functions, Function{
+			Name:              fn.Name,
+			Receiver:          fn.Receiver,
+			StartLine:         fn.StartLine,
+			EndLine:           fn.EndLine,
+			Statements:        fn.TotalStmts,
+			CoveredStatements: fn.CoveredStmts,
+			CoveragePct:       fn.CoveragePct,
+		})
+	}
+
+	for ln := 1; ln < len(f.PerLineStatus); ln++ {
+		status, err := coverage.LineStatus(f.PerLineStatus[ln]).String()
+		if err != nil {
+			continue
+		}
+
+		line := Line{Number: ln, Status: status}
+		if ln < len(f.PerLineHits) && f.PerLineHits[ln] >= 0 {
+			hits := f.PerLineHits[ln]
+			line.Hits = &hits
+		}
+		file.Lines = append(file.Lines, line)
+	}
+
+	return file
+}
+
+// totals aggregates statement and line coverage of the files
+func totals(files []*coverage.FileMetrics) Totals {
+	stats := coverage.Statistics(files)
+	t := Totals{
+		Files:             stats.TotalFiles,
+		Statements:        stats.TotalStmts,
+		CoveredStatements: stats.CoveredStmts,
+		CoveragePct:       stats.CoveragePct,
+	}
+
+	for _, f := range files {
+		t.TrackedLines += f.TrackedLines
+		t.CoveredLines += f.CoveredLines
+		t.PartialLines += f.PartialLines
+		t.MissedLines += f.MissedLines
+	}
+
+	return t
+}
@@ -0,0 +1,191 @@
+/*
+Copyright (c) Tobias Schäfer. All rights reserved.
+Licensed under the MIT license, see LICENSE in the project root for details.
+*/
+package report
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/tschaefer/cover-ui/internal/coverage"
+	"github.com/tschaefer/cover-ui/internal/exclude"
+	"github.com/tschaefer/cover-ui/internal/module"
+)
+
+var testModules = module.Modules{{Path: "github.com/test/repo", Dir: "."}}
+
+func testFiles() []*coverage.FileMetrics {
+	return []*coverage.FileMetrics{
+		{
+			FileName:      "github.com/test/repo/main.go",
+			LocalPath:     "main.go",
+			Mode:          "count",
+			TrackedLines:  2,
+			CoveredLines:  1,
+			MissedLines:   1,
+			TotalStmts:    4,
+			CoveredStmts:  2,
+			CoveragePct:   50,
+			PerLineStatus: []int{-1, -1, int(coverage.Covered), int(coverage.Missed)},
+			PerLineHits:   []int{-1, -1, 5, 0},
+			Functions: []coverage.FunctionMetrics{
+				{Name: "main", StartLine: 1, EndLine: 3, TotalStmts: 4, CoveredStmts: 2, CoveragePct: 50},
+			},
+		},
+		{
+			FileName:     "github.com/test/repo/pkg/utils.go",
+			LocalPath:    "pkg/utils.go",
+			Mode:         "count",
+			TrackedLines: 1,
+			CoveredLines: 1,
+			TotalStmts:   1,
+			CoveredStmts: 1,
+			CoveragePct:  100,
+		},
+	}
+}
+
+func TestBuild(t *testing.T) {
+	r := Build(testFiles(), nil, testModules, nil)
+
+	if r.SchemaVersion != SchemaVersion {
+		t.Errorf("Expected schema version %d, got %d", SchemaVersion, r.SchemaVersion)
+	}
+	if r.Mode != "count" {
+		t.Errorf("Expected mode count, got %s", r.Mode)
+	}
+	if r.Totals.Statements != 5 || r.Totals.CoveredStatements != 3 || r.Totals.CoveragePct != 60 {
+		t.Errorf("Unexpected totals %+v", r.Totals)
+	}
+	if r.Patch != nil {
+		t.Errorf("Expected no patch, got %+v", r.Patch)
+	}
+
+	if len(r.Packages) != 2 {
+		t.Fatalf("Expected 2 packages, got %d", len(r.Packages))
+	}
+	if r.Packages[0].ImportPath != "github.com/test/repo/pkg" || r.Packages[0].Dir != "pkg" {
+		t.Errorf("Unexpected first package %+v", r.Packages[0])
+	}
+	if r.Packages[1].ImportPath != "github.com/test/repo" || r.Packages[1].Dir != "." {
+		t.Errorf("Unexpected second package %+v", r.Packages[1])
+	}
+
+	main := r.Files[1]
+	if main.Path != "main.go" || main.Package != "github.com/test/repo" {
+		t.Errorf("Unexpected file %+v", main)
+	}
+	if len(main.Lines) != 2 {
+		t.Fatalf("Expected 2 tracked lines, got %d", len(main.Lines))
+	}
+	if main.Lines[0].Number != 2 || main.Lines[0].Status != "covered" || *main.Lines[0].Hits != 5 {
+		t.Errorf("Unexpected line %+v", main.Lines[0])
+	}
+	if main.Lines[1].Status != "missed" || *main.Lines[1].Hits != 0 {
+		t.Errorf("Unexpected line %+v", main.Lines[1])
+	}
+	if len(main.Functions) != 1 || main.Functions[0].Statements != 4 {
+		t.Errorf("Unexpected functions %+v", main.Functions)
+	}
+}
+
+func TestBuildPatch(t *testing.T) {
+	patch := &coverage.PatchMetrics{Base: "main", TotalFiles: 1, TrackedLines: 2, CoveredLines: 1, CoveragePct: 50}
+
+	r := Build(testFiles(), nil, testModules, patch)
+	if r.Patch == nil || r.Patch.Base != "main" || r.Patch.CoveragePct != 50 {
+		t.Errorf("Unexpected patch %+v", r.Patch)
+	}
+}
+
+func TestBuildExcluded(t *testing.T) {
+	if r := Build(testFiles(), nil, testModules, nil); r.Excluded == nil || len(r.Excluded) != 0 {
+		t.Errorf("Expected empty excluded list, got %v", r.Excluded)
+	}
+
+	excluded := []exclude.File{{FileName: "github.com/test/repo/api/api.pb.go", LocalPath: "api/api.pb.go", Reason: exclude.ReasonPattern, Pattern: "*.pb.go"}}
+	r := Build(testFiles(), excluded, testModules, nil)
+	if len(r.Excluded) != 1 || r.Excluded[0] != excluded[0] {
+		t.Errorf("Expected excluded files %v, got %v", excluded, r.Excluded)
+	}
+	if r.Totals.Files != 2 {
+		t.Errorf("Expected excluded files not to count, got %d files", r.Totals.Files)
+	}
+}
+
+func TestBuildModules(t *testing.T) {
+	files := testFiles()
+	for _, f := range files {
+		f.Module = "github.com/test/repo"
+		f.Package = "github.com/test/repo"
+	}
+	files = append(files, &coverage.FileMetrics{
+		FileName:     "github.com/test/tools/gen/gen.go",
+		LocalPath:    "tools/gen/gen.go",
+		Module:       "github.com/test/tools",
+		Package:      "github.com/test/tools/gen",
+		Mode:         "count",
+		TotalStmts:   2,
+		CoveredStmts: 0,
+	})
+	files[1].Package = "github.com/test/repo/pkg"
+
+	modules := module.Modules{
+		{Path: "github.com/test/repo", Dir: "."},
+		{Path: "github.com/test/tools", Dir: "tools"},
+		{Path: "github.com/test/empty", Dir: "empty"},
+	}
+	r := Build(files, nil, modules, nil)
+
+	if r.Module != "github.com/test/repo" {
+		t.Errorf("Expected main module github.com/test/repo, got %s", r.Module)
+	}
+	if len(r.Modules) != 2 {
+		t.Fatalf("Expected 2 modules with files, got %d", len(r.Modules))
+	}
+	if r.Modules[0].Path != "github.com/test/repo" || r.Modules[0].Totals.Statements != 5 {
+		t.Errorf("Unexpected first module %+v", r.Modules[0])
+	}
+	if r.Modules[1].Path != "github.com/test/tools" || r.Modules[1].Dir != "tools" || r.Modules[1].Totals.Statements != 2 {
+		t.Errorf("Unexpected second module %+v", r.Modules[1])
+	}
+
+	var importPaths []string
+	for _, pkg := range r.Packages {
+		importPaths = append(importPaths, pkg.ImportPath)
+	}
+	want := []string{"github.com/test/repo/pkg", "github.com/test/tools/gen", "github.com/test/repo"}
+	if len(importPaths) != len(want) {
+		t.Fatalf("Expected packages %v, got %v", want, importPaths)
+	}
+	for i := range want {
+		if importPaths[i] != want[i] {
+			t.Errorf("Expected packages %v, got %v", want, importPaths)
+			break
+		}
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	outDir := t.TempDir()
+
+	if err := Generate(testFiles(), nil, outDir, testModules, nil); err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+
+	content, err := os.ReadFile(filepath.Join(outDir, FileName))
+	if err != nil {
+		t.Fatalf("Expected %s to exist: %v", FileName, err)
+	}
+
+	var r Report
+	if err := json.Unmarshal(content, &r); err != nil {
+		t.Fatalf("Expected valid JSON, got %v", err)
+	}
+	if len(r.Files) != 2 {
+		t.Errorf("Expected 2 files, got %d", len(r.Files))
+	}
+}
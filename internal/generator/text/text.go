@@ -0,0 +1,182 @@
+/*
+Copyright (c) Tobias Schäfer. All rights reserved.
+Licensed under the MIT license, see LICENSE in the project root for details.
+*/
+package text
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/tschaefer/cover-ui/internal/coverage"
+	"github.com/tschaefer/cover-ui/internal/tree"
+)
+
+// Sort orders of the table rows within a directory
+const (
+	SortName     = "name"
+	SortCoverage = "coverage"
+	SortMissed   = "missed"
+)
+
+// SortOrders lists the supported sort orders
+var SortOrders = []string{SortName, SortCoverage, SortMissed}
+
+// ANSI escape sequences of the coverage colors
+const (
+	ansiRed    = "\033[31m"
+	ansiYellow = "\033[33m"
+	ansiGreen  = "\033[32m"
+	ansiBold   = "\033[1m"
+	ansiReset  = "\033[0m"
+)
+
+// Options configure the table
+type Options struct {
+	// Depth limits the directory levels shown, 0 shows all levels
+	Depth int
+	// Sort is the order of the rows within a directory, one of SortOrders
+	Sort string
+	// Color colors the coverage column
+	Color bool
+}
+
+// DefaultOptions returns options of an unlimited table sorted by name,
+// colored unless NO_COLOR is set
+func DefaultOptions() Options {
+	return Options{Sort: SortName, Color: os.Getenv("NO_COLOR") == ""}
+}
+
+// Validate checks the sort order and depth
+func (o Options) Validate() error {
+	if o.Depth < 0 {
+		return fmt.Errorf("invalid depth %d, must not be negative", o.Depth)
+	}
+
+	for _, s := range SortOrders {
+		if o.Sort == s {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("unsupported sort order %s, must be one of %s", o.Sort, strings.Join(SortOrders, ", "))
+}
+
+// row is a table row of a directory or file
+type row struct {
+	name   string
+	stmts  int
+	missed int
+	pct    float64
+}
+
+// Write renders an aligned table of the directories and files with their
+// statements, missed statements and coverage, followed by the total
+func Write(w io.Writer, files []*coverage.FileMetrics, opts Options) error {
+	root := tree.Build(files)
+
+	var rows []row
+	var walk func(node *tree.Node, depth int)
+	walk = func(node *tree.Node, depth int) {
+		children := append([]*tree.Node(nil), node.Children...)
+		sortNodes(children, opts.Sort)
+
+		for _, child := range children {
+			name := strings.Repeat("  ", depth) + child.Name
+			if child.IsDir {
+				name += "/"
+			}
+			rows = append(rows, row{name, child.TotalStmts, child.TotalStmts - child.CoveredStmts, child.CoveragePct})
+
+			if child.IsDir && (opts.Depth == 0 || depth+1 < opts.Depth) {
+				walk(child, depth+1)
+			}
+		}
+	}
+	walk(root, 0)
+
+	total := coverage.Statistics(files)
+	totalRow := row{"Total", total.TotalStmts, total.TotalStmts - total.CoveredStmts, total.CoveragePct}
+
+	width := utf8.RuneCountInString(totalRow.name)
+	for _, r := range rows {
+		width = max(width, utf8.RuneCountInString(r.name))
+	}
+
+	bw := bufio.NewWriter(w)
+
+	header := fmt.Sprintf("%-*s  %10s  %10s  %8s", width, "Name", "Statements", "Missed", "Coverage")
+	_, _ = fmt.Fprintln(bw, style(header, ansiBold, opts.Color))
+	for _, r := range rows {
+		writeRow(bw, r, width, opts.Color)
+	}
+	_, _ = fmt.Fprintln(bw, strings.Repeat("-", utf8.RuneCountInString(header)))
+	writeRow(bw, totalRow, width, opts.Color)
+
+	return bw.Flush()
+}
+
+// writeRow writes a table row padded to the name column width
+func writeRow(w *bufio.Writer, r row, width int, color bool) {
+	pct := "-"
+	if r.stmts > 0 {
+		pct = fmt.Sprintf("%.1f%%", r.pct)
+	}
+	pct = fmt.Sprintf("%8s", pct)
+	if r.stmts > 0 {
+		pct = style(pct, coverageColor(r.pct), color)
+	}
+
+	padding := strings.Repeat(" ", width-utf8.RuneCountInString(r.name))
+	_, _ = fmt.Fprintf(w, "%s%s  %10d  %10d  %s\n", r.name, padding, r.stmts, r.missed, pct)
+}
+
+// style wraps a text in an ANSI escape sequence if color is enabled
+func style(text, seq string, color bool) string {
+	if !color {
+		return text
+	}
+
+	return seq + text + ansiReset
+}
+
+// coverageColor returns the nearest terminal color of the red/yellow/green
+// coverage band
+func coverageColor(pct float64) string {
+	switch {
+	case pct < 25:
+		return ansiRed
+	case pct < 75:
+		return ansiYellow
+	default:
+		return ansiGreen
+	}
+}
+
+// sortNodes orders the nodes of a directory, ties are ordered directories
+// first and by name
+func sortNodes(nodes []*tree.Node, order string) {
+	sort.SliceStable(nodes, func(i, j int) bool {
+		a, b := nodes[i], nodes[j]
+		switch order {
+		case SortCoverage:
+			if a.CoveragePct != b.CoveragePct {
+				return a.CoveragePct < b.CoveragePct
+			}
+		case SortMissed:
+			missedA, missedB := a.TotalStmts-a.CoveredStmts, b.TotalStmts-b.CoveredStmts
+			if missedA != missedB {
+				return missedA > missedB
+			}
+		}
+		if a.IsDir != b.IsDir {
+			return a.IsDir
+		}
+		return a.Name < b.Name
+	})
+}
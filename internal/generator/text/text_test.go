@@ -0,0 +1,95 @@
+/*
+Copyright (c) Tobias Schäfer. All rights reserved.
+Licensed under the MIT license, see LICENSE in the project root for details.
+*/
+package text
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/tschaefer/cover-ui/internal/coverage"
+)
+
+func testFiles() []*coverage.FileMetrics {
+	return []*coverage.FileMetrics{
+		{FileName: "example.com/mod/main.go", LocalPath: "main.go", TotalStmts: 4, CoveredStmts: 4, CoveragePct: 100},
+		{FileName: "example.com/mod/pkg/a.go", LocalPath: "pkg/a.go", TotalStmts: 4, CoveredStmts: 1, CoveragePct: 25},
+		{FileName: "example.com/mod/pkg/b.go", LocalPath: "pkg/b.go", TotalStmts: 2, CoveredStmts: 0, CoveragePct: 0},
+	}
+}
+
+func TestWrite(t *testing.T) {
+	var sb strings.Builder
+	if err := Write(&sb, testFiles(), Options{Sort: SortName}); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+
+	want := "" +
+		"Name     Statements      Missed  Coverage\n" +
+		"pkg/              6           5     16.7%\n" +
+		"  a.go            4           3     25.0%\n" +
+		"  b.go            2           2      0.0%\n" +
+		"main.go           4           0    100.0%\n" +
+		"-----------------------------------------\n" +
+		"Total            10           5     50.0%\n"
+
+	if sb.String() != want {
+		t.Errorf("Expected table:\n%s\ngot:\n%s", want, sb.String())
+	}
+}
+
+func TestWriteDepthAndSort(t *testing.T) {
+	var sb strings.Builder
+	if err := Write(&sb, testFiles(), Options{Depth: 1, Sort: SortMissed}); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(sb.String()), "\n")
+	if len(lines) != 5 {
+		t.Fatalf("Expected 5 lines, got %d:\n%s", len(lines), sb.String())
+	}
+	if !strings.HasPrefix(lines[1], "pkg/") || !strings.HasPrefix(lines[2], "main.go") {
+		t.Errorf("Expected pkg/ before main.go sorted by missed statements, got:\n%s", sb.String())
+	}
+
+	sb.Reset()
+	if err := Write(&sb, testFiles(), Options{Sort: SortCoverage}); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+
+	lines = strings.Split(sb.String(), "\n")
+	if !strings.HasPrefix(lines[2], "  b.go") || !strings.HasPrefix(lines[3], "  a.go") {
+		t.Errorf("Expected b.go before a.go sorted by coverage, got:\n%s", sb.String())
+	}
+}
+
+func TestWriteColor(t *testing.T) {
+	var sb strings.Builder
+	if err := Write(&sb, testFiles(), Options{Sort: SortName, Color: true}); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+
+	for _, want := range []string{
+		ansiBold + "Name",
+		ansiRed + "   16.7%" + ansiReset,
+		ansiYellow + "   25.0%" + ansiReset,
+		ansiGreen + "  100.0%" + ansiReset,
+	} {
+		if !strings.Contains(sb.String(), want) {
+			t.Errorf("Expected table to contain %q, got %q", want, sb.String())
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	if err := DefaultOptions().Validate(); err != nil {
+		t.Errorf("Expected default options to be valid, got %v", err)
+	}
+	if err := (Options{Sort: "size"}).Validate(); err == nil {
+		t.Error("Expected error for unsupported sort order")
+	}
+	if err := (Options{Sort: SortName, Depth: -1}).Validate(); err == nil {
+		t.Error("Expected error for negative depth")
+	}
+}
@@ -0,0 +1,89 @@
+/*
+Copyright (c) Tobias Schäfer. All rights reserved.
+Licensed under the MIT license, see LICENSE in the project root for details.
+*/
+package gotest
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"golang.org/x/tools/cover"
+)
+
+// Packages returns the package directories of the changed Go files,
+// relative to root in slash notation, sorted and without duplicates
+func Packages(root string, changed []string) []string {
+	seen := make(map[string]bool)
+	var dirs []string
+	for _, path := range changed {
+		if !strings.HasSuffix(path, ".go") {
+			continue
+		}
+
+		rel, err := filepath.Rel(root, filepath.Dir(path))
+		if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			continue
+		}
+
+		dir := filepath.ToSlash(rel)
+		if !seen[dir] {
+			seen[dir] = true
+			dirs = append(dirs, dir)
+		}
+	}
+	sort.Strings(dirs)
+
+	return dirs
+}
+
+// HasGoFiles reports whether the directory holds any Go files
+func HasGoFiles(dir string) bool {
+	matches, _ := filepath.Glob(filepath.Join(dir, "*.go"))
+	return len(matches) > 0
+}
+
+// Run runs go test with a coverage profile for the package directories,
+// relative to root, and returns the parsed profiles with the test output.
+// Failing tests still yield the profiles of the packages that were run,
+// so profiles may be returned along with an error.
+func Run(ctx context.Context, root string, mode string, dirs []string) ([]*cover.Profile, []byte, error) {
+	out, err := os.CreateTemp("", "gocover-ui-*.out")
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create profile file: %w", err)
+	}
+	_ = out.Close()
+	defer func() {
+		_ = os.Remove(out.Name())
+	}()
+
+	args := []string{"test", "-coverprofile=" + out.Name()}
+	if mode != "" {
+		args = append(args, "-covermode="+mode)
+	}
+	for _, dir := range dirs {
+		args = append(args, "./"+strings.TrimPrefix(dir, "./"))
+	}
+
+	cmd := exec.CommandContext(ctx, "go", args...)
+	cmd.Dir = root
+	output, runErr := cmd.CombinedOutput()
+	if runErr != nil {
+		runErr = fmt.Errorf("go test failed: %w", runErr)
+	}
+
+	profiles, err := cover.ParseProfiles(out.Name())
+	if err != nil {
+		if runErr != nil {
+			return nil, output, runErr
+		}
+		return nil, output, fmt.Errorf("failed to parse coverage profile: %w", err)
+	}
+
+	return profiles, output, runErr
+}
@@ -0,0 +1,88 @@
+/*
+Copyright (c) Tobias Schäfer. All rights reserved.
+Licensed under the MIT license, see LICENSE in the project root for details.
+*/
+package gotest
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func writeFile(t *testing.T, path, content string) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatalf("Failed to create directory: %v", err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+}
+
+func TestPackages(t *testing.T) {
+	root := filepath.Join("repo")
+	changed := []string{
+		filepath.Join(root, "pkg", "a.go"),
+		filepath.Join(root, "pkg", "a_test.go"),
+		filepath.Join(root, "main.go"),
+		filepath.Join(root, "README.md"),
+		filepath.Join("other", "x.go"),
+	}
+
+	want := []string{".", "pkg"}
+	if got := Packages(root, changed); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected packages %v, got %v", want, got)
+	}
+}
+
+func TestRun(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "go.mod"), "module example.com/calc\n\ngo 1.21\n")
+	writeFile(t, filepath.Join(root, "calc", "calc.go"), `package calc
+
+func Abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
+`)
+	writeFile(t, filepath.Join(root, "calc", "calc_test.go"), `package calc
+
+import "testing"
+
+func TestAbs(t *testing.T) {
+	if Abs(1) != 1 {
+		t.Fail()
+	}
+}
+`)
+
+	if !HasGoFiles(filepath.Join(root, "calc")) || HasGoFiles(root) {
+		t.Fatalf("Expected Go files only in calc directory")
+	}
+
+	profiles, _, err := Run(context.Background(), root, "count", []string{"calc"})
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+
+	if len(profiles) != 1 || profiles[0].FileName != "example.com/calc/calc/calc.go" {
+		t.Fatalf("Expected profile of calc.go, got %+v", profiles)
+	}
+	if profiles[0].Mode != "count" {
+		t.Errorf("Expected mode count, got %s", profiles[0].Mode)
+	}
+
+	covered := 0
+	for _, b := range profiles[0].Blocks {
+		if b.Count > 0 {
+			covered++
+		}
+	}
+	if covered == 0 || covered == len(profiles[0].Blocks) {
+		t.Errorf("Expected partially covered blocks, got %d of %d", covered, len(profiles[0].Blocks))
+	}
+}
@@ -0,0 +1,96 @@
+/*
+Copyright (c) Tobias Schäfer. All rights reserved.
+Licensed under the MIT license, see LICENSE in the project root for details.
+*/
+package history
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"time"
+
+	"github.com/tschaefer/cover-ui/internal/coverage"
+)
+
+// Entry holds the totals of a single run, one JSON object per line of the
+// history file
+type Entry struct {
+	Timestamp time.Time                        `json:"timestamp"`
+	Commit    string                           `json:"commit,omitempty"`
+	Totals    coverage.TotalMetrics            `json:"totals"`
+	Packages  map[string]coverage.TotalMetrics `json:"packages"`
+}
+
+// NewEntry creates the entry of a run, packages are keyed by directory
+func NewEntry(files []*coverage.FileMetrics, commit string, timestamp time.Time) Entry {
+	packages := make(map[string]coverage.TotalMetrics)
+	for _, pkg := range coverage.Packages(files) {
+		packages[pkg.Dir] = *coverage.Statistics(pkg.Files)
+	}
+
+	return Entry{
+		Timestamp: timestamp.UTC().Truncate(time.Second),
+		Commit:    commit,
+		Totals:    *coverage.Statistics(files),
+		Packages:  packages,
+	}
+}
+
+// Read returns the entries of a history file in order, a missing file has
+// no entries
+func Read(filePath string) ([]Entry, error) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to open history file: %w", err)
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+
+	var entries []Entry
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for n := 1; scanner.Scan(); n++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+
+		var e Entry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			return nil, fmt.Errorf("failed to parse history file %s line %d: %w", filePath, n, err)
+		}
+		entries = append(entries, e)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read history file: %w", err)
+	}
+
+	return entries, nil
+}
+
+// Append adds an entry to the end of a history file, creating it if needed
+func Append(filePath string, e Entry) error {
+	line, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("failed to marshal history entry: %w", err)
+	}
+
+	f, err := os.OpenFile(filePath, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to open history file: %w", err)
+	}
+
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		_ = f.Close()
+		return fmt.Errorf("failed to write history file: %w", err)
+	}
+
+	return f.Close()
+}
@@ -0,0 +1,84 @@
+/*
+Copyright (c) Tobias Schäfer. All rights reserved.
+Licensed under the MIT license, see LICENSE in the project root for details.
+*/
+package history
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/tschaefer/cover-ui/internal/coverage"
+)
+
+func TestNewEntry(t *testing.T) {
+	files := []*coverage.FileMetrics{
+		{LocalPath: "main.go", Package: "example.com/mod", TotalStmts: 4, CoveredStmts: 1},
+		{LocalPath: "pkg/a.go", Package: "example.com/mod/pkg", TotalStmts: 2, CoveredStmts: 2},
+		{LocalPath: "pkg/b.go", Package: "example.com/mod/pkg", TotalStmts: 2, CoveredStmts: 0},
+		{LocalPath: "/abs/c.go", TotalStmts: 1, CoveredStmts: 1},
+	}
+
+	now := time.Date(2026, 5, 1, 12, 0, 0, 500, time.FixedZone("CEST", 2*60*60))
+	e := NewEntry(files, "abc123", now)
+
+	if !e.Timestamp.Equal(now.Truncate(time.Second)) || e.Timestamp.Location() != time.UTC {
+		t.Errorf("Unexpected timestamp %v", e.Timestamp)
+	}
+	if e.Totals.TotalStmts != 9 || e.Totals.CoveredStmts != 4 || e.Totals.TotalFiles != 4 {
+		t.Errorf("Unexpected totals %+v", e.Totals)
+	}
+	if len(e.Packages) != 3 {
+		t.Fatalf("Expected 3 packages, got %d", len(e.Packages))
+	}
+	if pkg := e.Packages["pkg"]; pkg.TotalFiles != 2 || pkg.CoveragePct != 50 {
+		t.Errorf("Unexpected package totals %+v", pkg)
+	}
+	if _, ok := e.Packages["."]; !ok {
+		t.Errorf("Expected the root package keyed by ., got %v", e.Packages)
+	}
+	if _, ok := e.Packages["/abs"]; !ok {
+		t.Errorf("Expected files outside the source root keyed by directory, got %v", e.Packages)
+	}
+}
+
+func TestAppendRead(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "history.jsonl")
+
+	entries, err := Read(filePath)
+	if err != nil || entries != nil {
+		t.Fatalf("Expected no entries for missing file, got %v (%v)", entries, err)
+	}
+
+	files := []*coverage.FileMetrics{{LocalPath: "main.go", Package: "example.com/mod", TotalStmts: 2, CoveredStmts: 1}}
+	first := NewEntry(files, "abc", time.Unix(1000, 0))
+	second := NewEntry(files, "", time.Unix(2000, 0))
+	for _, e := range []Entry{first, second} {
+		if err := Append(filePath, e); err != nil {
+			t.Fatalf("Append failed: %v", err)
+		}
+	}
+
+	entries, err = Read(filePath)
+	if err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("Expected 2 entries, got %d", len(entries))
+	}
+	if entries[0].Commit != "abc" || !entries[1].Timestamp.Equal(second.Timestamp) {
+		t.Errorf("Unexpected entries %+v", entries)
+	}
+	if entries[0].Packages["."].CoveragePct != 50 {
+		t.Errorf("Unexpected package totals %+v", entries[0].Packages)
+	}
+
+	if err := os.WriteFile(filePath, []byte("{\"totals\":{}}\nnot json\n"), 0o644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+	if _, err := Read(filePath); err == nil {
+		t.Errorf("Expected error for invalid line, got nil")
+	}
+}
@@ -0,0 +1,95 @@
+/*
+Copyright (c) Tobias Schäfer. All rights reserved.
+Licensed under the MIT license, see LICENSE in the project root for details.
+*/
+package manifest
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+)
+
+// FileName is the name of the manifest within the output directory
+const FileName = "manifest.json"
+
+// version is increased on every incompatible change of the manifest,
+// manifests of other versions are ignored
+const version = 1
+
+// Manifest records the inputs of the generated file pages, so unchanged
+// pages are skipped on the next run
+type Manifest struct {
+	Version int `json:"version"`
+	// Generator is the fingerprint of the page templates and the generator
+	// version, all pages are stale if it changes
+	Generator string `json:"generator"`
+	// Pages maps the local path of every generated page to the hash of its
+	// inputs
+	Pages map[string]string `json:"pages"`
+}
+
+// New returns an empty manifest of a generator
+func New(generator string) *Manifest {
+	return &Manifest{Version: version, Generator: generator, Pages: make(map[string]string)}
+}
+
+// Read returns the manifest of a file, a missing file or a manifest of
+// another version has no pages
+func Read(filePath string) (*Manifest, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return New(""), nil
+		}
+		return nil, fmt.Errorf("failed to read manifest: %w", err)
+	}
+
+	var m Manifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("failed to parse manifest %s: %w", filePath, err)
+	}
+	if m.Version != version || m.Pages == nil {
+		return New(""), nil
+	}
+
+	return &m, nil
+}
+
+// Write stores the manifest in a file
+func (m *Manifest) Write(filePath string) error {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal manifest: %w", err)
+	}
+
+	if err := os.WriteFile(filePath, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("failed to write manifest: %w", err)
+	}
+
+	return nil
+}
+
+// Unchanged reports whether the manifest records the page of a local path
+// as generated by the generator from inputs of the hash
+func (m *Manifest) Unchanged(generator, localPath, hash string) bool {
+	recorded, ok := m.Pages[localPath]
+	return ok && m.Generator == generator && recorded == hash
+}
+
+// Hash returns the hex encoded SHA-256 hash of the parts, each part is
+// length prefixed so that moving bytes between parts changes the hash
+func Hash(parts ...[]byte) string {
+	h := sha256.New()
+	for _, p := range parts {
+		_ = binary.Write(h, binary.BigEndian, uint64(len(p)))
+		h.Write(p)
+	}
+
+	return hex.EncodeToString(h.Sum(nil))
+}
@@ -0,0 +1,77 @@
+/*
+Copyright (c) Tobias Schäfer. All rights reserved.
+Licensed under the MIT license, see LICENSE in the project root for details.
+*/
+package manifest
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestReadWrite(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), FileName)
+
+	m, err := Read(filePath)
+	if err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+	if len(m.Pages) != 0 {
+		t.Errorf("Expected no pages for a missing manifest, got %d", len(m.Pages))
+	}
+
+	m = New("gen")
+	m.Pages["main.go"] = Hash([]byte("source"))
+	if err := m.Write(filePath); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+
+	read, err := Read(filePath)
+	if err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+	if !read.Unchanged("gen", "main.go", Hash([]byte("source"))) {
+		t.Errorf("Expected main.go to be unchanged, got %+v", read)
+	}
+	if read.Unchanged("other", "main.go", Hash([]byte("source"))) {
+		t.Error("Expected main.go to be stale for another generator")
+	}
+	if read.Unchanged("gen", "main.go", Hash([]byte("changed"))) {
+		t.Error("Expected main.go to be stale for changed inputs")
+	}
+	if read.Unchanged("gen", "other.go", "") {
+		t.Error("Expected unknown page to be stale")
+	}
+}
+
+func TestReadVersion(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), FileName)
+	if err := os.WriteFile(filePath, []byte(`{"version": 99, "generator": "gen", "pages": {"main.go": "x"}}`), 0o644); err != nil {
+		t.Fatalf("Failed to write manifest: %v", err)
+	}
+
+	m, err := Read(filePath)
+	if err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+	if len(m.Pages) != 0 {
+		t.Errorf("Expected manifest of another version to be ignored, got %+v", m)
+	}
+
+	if err := os.WriteFile(filePath, []byte(`{`), 0o644); err != nil {
+		t.Fatalf("Failed to write manifest: %v", err)
+	}
+	if _, err := Read(filePath); err == nil {
+		t.Error("Expected error for invalid manifest")
+	}
+}
+
+func TestHash(t *testing.T) {
+	if Hash([]byte("ab"), []byte("c")) == Hash([]byte("a"), []byte("bc")) {
+		t.Error("Expected different hashes for differently split parts")
+	}
+	if Hash([]byte("a")) != Hash([]byte("a")) {
+		t.Error("Expected equal hashes for equal parts")
+	}
+}
@@ -5,23 +5,243 @@ Licensed under the MIT license, see LICENSE in the project root for details.
 package module
 
 import (
+	"errors"
 	"fmt"
+	"io/fs"
 	"os"
+	"path"
 	"path/filepath"
+	"sort"
+	"strings"
+
+	"golang.org/x/mod/modfile"
 )
 
+// Module is a Go module within the source root
+type Module struct {
+	// Path is the module path declared in go.mod
+	Path string
+	// Dir is the module directory relative to the source root in slash
+	// notation, "." for the source root itself
+	Dir string
+}
+
+// Modules holds the modules of a source root, sorted by directory
+type Modules []Module
+
 // Read parses the go.mod file located at path and returns the module name.
 func Read(path string) (string, error) {
-	goModFile, err := os.ReadFile(filepath.Join(path, "go.mod"))
+	goModPath := filepath.Join(path, "go.mod")
+	goModFile, err := os.ReadFile(goModPath)
 	if err != nil {
 		return "", fmt.Errorf("failed to read go.mod file: %w", err)
 	}
 
-	var module string
-	_, err = fmt.Sscanf(string(goModFile), "module %s", &module)
+	f, err := modfile.ParseLax(goModPath, goModFile, nil)
 	if err != nil {
-		return "", fmt.Errorf("failed to parse module name: %w", err)
+		return "", fmt.Errorf("failed to parse go.mod file: %w", err)
+	}
+	if f.Module == nil || f.Module.Mod.Path == "" {
+		return "", fmt.Errorf("failed to parse module name: no module directive in %s", goModPath)
+	}
+
+	return f.Module.Mod.Path, nil
+}
+
+// Discover returns the modules of the source root. A go.work file defines
+// the modules by its use directives, otherwise every go.mod file below the
+// root declares one, skipping vendor, testdata and hidden directories.
+func Discover(root string) (Modules, error) {
+	goWorkPath := filepath.Join(root, "go.work")
+	goWorkFile, err := os.ReadFile(goWorkPath)
+	switch {
+	case err == nil:
+		return readWork(root, goWorkPath, goWorkFile)
+	case !errors.Is(err, fs.ErrNotExist):
+		return nil, fmt.Errorf("failed to read go.work file: %w", err)
+	}
+
+	var modules Modules
+	err = filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			name := d.Name()
+			if p != root && (name == "vendor" || name == "testdata" || strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_")) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.Name() != "go.mod" {
+			return nil
+		}
+
+		dir := filepath.Dir(p)
+		modulePath, err := Read(dir)
+		if err != nil {
+			return err
+		}
+		modules = append(modules, Module{Path: modulePath, Dir: relDir(root, dir)})
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to discover modules: %w", err)
+	}
+
+	if len(modules) == 0 {
+		return nil, fmt.Errorf("failed to read go.mod file: no module found in %s", root)
+	}
+
+	return sorted(modules), nil
+}
+
+// readWork returns the modules used by a go.work file
+func readWork(root, goWorkPath string, goWorkFile []byte) (Modules, error) {
+	f, err := modfile.ParseWork(goWorkPath, goWorkFile, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse go.work file: %w", err)
+	}
+
+	var modules Modules
+	for _, use := range f.Use {
+		dir := filepath.FromSlash(use.Path)
+		if !filepath.IsAbs(dir) {
+			dir = filepath.Join(root, dir)
+		}
+
+		modulePath, err := Read(dir)
+		if err != nil {
+			return nil, err
+		}
+		modules = append(modules, Module{Path: modulePath, Dir: relDir(root, dir)})
+	}
+
+	if len(modules) == 0 {
+		return nil, fmt.Errorf("failed to parse go.work file: no use directive in %s", goWorkPath)
+	}
+
+	return sorted(modules), nil
+}
+
+// relDir returns the directory relative to the root in slash notation
+func relDir(root, dir string) string {
+	rel, err := filepath.Rel(root, dir)
+	if err != nil {
+		return filepath.ToSlash(dir)
+	}
+
+	return filepath.ToSlash(rel)
+}
+
+func sorted(modules Modules) Modules {
+	sort.Slice(modules, func(i, j int) bool {
+		return modules[i].Dir < modules[j].Dir
+	})
+
+	return modules
+}
+
+// Main returns the module of the source root or, without one, the first
+// module
+func (m Modules) Main() Module {
+	for _, mod := range m {
+		if mod.Dir == "." {
+			return mod
+		}
+	}
+	if len(m) > 0 {
+		return m[0]
+	}
+
+	return Module{}
+}
+
+// Match returns the module a profile file name belongs to and the path of
+// the file relative to the module directory. The module with the longest
+// matching path wins, so files of nested modules map to the inner module.
+func (m Modules) Match(name string) (Module, string, bool) {
+	var match Module
+	found := false
+	for _, mod := range m {
+		if !strings.HasPrefix(name, mod.Path+"/") {
+			continue
+		}
+		if !found || len(mod.Path) > len(match.Path) {
+			match, found = mod, true
+		}
 	}
 
-	return module, nil
+	if !found {
+		return Module{}, "", false
+	}
+
+	return match, strings.TrimPrefix(name, match.Path+"/"), true
+}
+
+// Package returns the module holding a directory, relative to the source
+// root in slash notation, and the import path of the directory. The module
+// with the longest matching directory wins.
+func (m Modules) Package(dir string) (Module, string, bool) {
+	dir = path.Clean(dir)
+
+	var match Module
+	found := false
+	for _, mod := range m {
+		if !contains(mod.Dir, dir) {
+			continue
+		}
+		if !found || specificity(mod) > specificity(match) {
+			match, found = mod, true
+		}
+	}
+
+	if !found {
+		return Module{}, "", false
+	}
+
+	rel := match.Rel(dir)
+	if rel == "." {
+		return match, match.Path, true
+	}
+
+	return match, match.Path + "/" + rel, true
+}
+
+// Rel returns a directory within the module, relative to the source root,
+// relative to the module directory
+func (mod Module) Rel(dir string) string {
+	dir = path.Clean(dir)
+	switch {
+	case mod.Dir == ".":
+		return dir
+	case dir == mod.Dir:
+		return "."
+	default:
+		return strings.TrimPrefix(dir, mod.Dir+"/")
+	}
+}
+
+// contains reports whether the directory is the module directory or below
+func contains(moduleDir, dir string) bool {
+	if moduleDir == "." {
+		return dir != ".." && !strings.HasPrefix(dir, "../") && !path.IsAbs(dir)
+	}
+
+	return dir == moduleDir || strings.HasPrefix(dir, moduleDir+"/")
+}
+
+// specificity ranks modules by the depth of their directory
+func specificity(mod Module) int {
+	if mod.Dir == "." {
+		return 0
+	}
+
+	return len(mod.Dir)
+}
+
+// LocalPath returns the path of a file within the module relative to the
+// source root
+func (mod Module) LocalPath(rel string) string {
+	return path.Join(mod.Dir, rel)
 }
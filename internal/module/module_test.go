@@ -7,6 +7,7 @@ package module
 import (
 	"os"
 	"path/filepath"
+	"reflect"
 	"testing"
 )
 
@@ -49,3 +50,138 @@ func TestReadMalformedGoMod(t *testing.T) {
 		t.Fatal("Expected error for malformed go.mod, got nil")
 	}
 }
+
+func TestReadCommentsAndBlankLines(t *testing.T) {
+	tmpDir := createGoMod(t, "\n// The example project\n\nmodule \"github.com/example/project\" // quoted\n\ngo 1.21\n")
+
+	moduleName, err := Read(tmpDir)
+	if err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+
+	if moduleName != "github.com/example/project" {
+		t.Errorf("Expected module name %q, got %q", "github.com/example/project", moduleName)
+	}
+}
+
+func writeFile(t *testing.T, path, content string) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatalf("Failed to create directory: %v", err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+}
+
+func TestDiscoverNested(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "go.mod"), "module example.com/root\n")
+	writeFile(t, filepath.Join(root, "tools", "go.mod"), "module example.com/root/tools\n")
+	writeFile(t, filepath.Join(root, "vendor", "x", "go.mod"), "module example.com/x\n")
+	writeFile(t, filepath.Join(root, "testdata", "go.mod"), "module example.com/testdata\n")
+
+	modules, err := Discover(root)
+	if err != nil {
+		t.Fatalf("Discover failed: %v", err)
+	}
+
+	want := Modules{
+		{Path: "example.com/root", Dir: "."},
+		{Path: "example.com/root/tools", Dir: "tools"},
+	}
+	if !reflect.DeepEqual(modules, want) {
+		t.Errorf("Expected modules %v, got %v", want, modules)
+	}
+}
+
+func TestDiscoverWorkspace(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "go.work"), "go 1.21\n\nuse (\n\t./api\n\t./service // main service\n)\n")
+	writeFile(t, filepath.Join(root, "api", "go.mod"), "module example.com/api\n")
+	writeFile(t, filepath.Join(root, "service", "go.mod"), "module example.com/service\n")
+	writeFile(t, filepath.Join(root, "unused", "go.mod"), "module example.com/unused\n")
+
+	modules, err := Discover(root)
+	if err != nil {
+		t.Fatalf("Discover failed: %v", err)
+	}
+
+	want := Modules{
+		{Path: "example.com/api", Dir: "api"},
+		{Path: "example.com/service", Dir: "service"},
+	}
+	if !reflect.DeepEqual(modules, want) {
+		t.Errorf("Expected modules %v, got %v", want, modules)
+	}
+	if main := modules.Main(); main != want[0] {
+		t.Errorf("Expected main module %v, got %v", want[0], main)
+	}
+}
+
+func TestDiscoverNoModule(t *testing.T) {
+	if _, err := Discover(t.TempDir()); err == nil {
+		t.Fatal("Expected error without any go.mod, got nil")
+	}
+}
+
+func TestMatch(t *testing.T) {
+	modules := Modules{
+		{Path: "example.com/root", Dir: "."},
+		{Path: "example.com/root/tools", Dir: "tools"},
+	}
+
+	tests := []struct {
+		name   string
+		module string
+		local  string
+		ok     bool
+	}{
+		{"example.com/root/main.go", "example.com/root", "main.go", true},
+		{"example.com/root/pkg/a.go", "example.com/root", "pkg/a.go", true},
+		{"example.com/root/tools/gen/gen.go", "example.com/root/tools", "tools/gen/gen.go", true},
+		{"example.com/rootless/main.go", "", "", false},
+	}
+
+	for _, tt := range tests {
+		mod, rel, ok := modules.Match(tt.name)
+		if ok != tt.ok || mod.Path != tt.module {
+			t.Errorf("Expected %s to match module %q (%v), got %q (%v)", tt.name, tt.module, tt.ok, mod.Path, ok)
+			continue
+		}
+		if ok && mod.LocalPath(rel) != tt.local {
+			t.Errorf("Expected local path %s, got %s", tt.local, mod.LocalPath(rel))
+		}
+	}
+}
+
+func TestPackage(t *testing.T) {
+	modules := Modules{
+		{Path: "example.com/root", Dir: "."},
+		{Path: "example.com/root/tools", Dir: "tools"},
+		{Path: "example.com/api", Dir: "api"},
+	}
+
+	tests := []struct {
+		dir        string
+		module     string
+		importPath string
+	}{
+		{".", "example.com/root", "example.com/root"},
+		{"pkg/sub", "example.com/root", "example.com/root/pkg/sub"},
+		{"tools", "example.com/root/tools", "example.com/root/tools"},
+		{"tools/gen", "example.com/root/tools", "example.com/root/tools/gen"},
+		{"api/v1", "example.com/api", "example.com/api/v1"},
+		{"apiv2", "example.com/root", "example.com/root/apiv2"},
+	}
+
+	for _, tt := range tests {
+		mod, importPath, ok := modules.Package(tt.dir)
+		if !ok || mod.Path != tt.module || importPath != tt.importPath {
+			t.Errorf("Expected %s in module %s as %s, got %s as %s (%v)", tt.dir, tt.module, tt.importPath, mod.Path, importPath, ok)
+		}
+	}
+
+	if _, _, ok := modules.Package("../outside"); ok {
+		t.Errorf("Expected directory outside the source root not to match")
+	}
+}
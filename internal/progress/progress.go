@@ -0,0 +1,87 @@
+/*
+Copyright (c) Tobias Schäfer. All rights reserved.
+Licensed under the MIT license, see LICENSE in the project root for details.
+*/
+package progress
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+)
+
+// barWidth is the number of cells of the terminal progress bar
+const barWidth = 30
+
+// logStep is the percentage step between progress lines in non-terminal
+// output, e.g. CI logs
+const logStep = 10
+
+// Bar reports the progress of a fixed number of steps. On terminals a
+// single line is redrawn and cleared when done, other writers get a line
+// every ten percent. Bar is safe for concurrent use.
+type Bar struct {
+	mu      sync.Mutex
+	w       io.Writer
+	label   string
+	total   int
+	done    int
+	tty     bool
+	lastPct int
+}
+
+// New returns a progress bar of total steps writing to w
+func New(w io.Writer, label string, total int) *Bar {
+	return &Bar{w: w, label: label, total: total, tty: isTerminal(w)}
+}
+
+// isTerminal reports whether w is a character device
+func isTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+// Increment completes a step, item names the completed step on terminals
+func (b *Bar) Increment(item string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.done++
+	pct := 100
+	if b.total > 0 {
+		pct = b.done * 100 / b.total
+	}
+
+	if b.tty {
+		filled := pct * barWidth / 100
+		_, _ = fmt.Fprintf(b.w, "\x1b[2K\r%s [%s%s] %d/%d %s",
+			b.label, strings.Repeat("#", filled), strings.Repeat("-", barWidth-filled), b.done, b.total, item)
+		return
+	}
+
+	if pct/logStep > b.lastPct/logStep {
+		b.lastPct = pct
+		_, _ = fmt.Fprintf(b.w, "%s: %d/%d (%d%%)\n", b.label, b.done, b.total, pct)
+	}
+}
+
+// Finish clears the progress line on terminals
+func (b *Bar) Finish() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.tty && b.done > 0 {
+		_, _ = fmt.Fprint(b.w, "\x1b[2K\r")
+	}
+}
@@ -0,0 +1,70 @@
+/*
+Copyright (c) Tobias Schäfer. All rights reserved.
+Licensed under the MIT license, see LICENSE in the project root for details.
+*/
+package progress
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestBarLog(t *testing.T) {
+	var sb strings.Builder
+	bar := New(&sb, "Generating pages", 25)
+	for i := range 25 {
+		bar.Increment(fmt.Sprintf("file%d.html", i))
+	}
+	bar.Finish()
+
+	lines := strings.Split(strings.TrimSpace(sb.String()), "\n")
+	if len(lines) != 10 {
+		t.Fatalf("Expected 10 progress lines, got %d:\n%s", len(lines), sb.String())
+	}
+	if lines[0] != "Generating pages: 3/25 (12%)" {
+		t.Errorf("Expected first line at 12%%, got %q", lines[0])
+	}
+	if lines[9] != "Generating pages: 25/25 (100%)" {
+		t.Errorf("Expected last line at 100%%, got %q", lines[9])
+	}
+	if strings.Contains(sb.String(), "\x1b") {
+		t.Errorf("Expected no escape sequences in log output, got %q", sb.String())
+	}
+}
+
+func TestBarTerminal(t *testing.T) {
+	var sb strings.Builder
+	bar := New(&sb, "Generating pages", 4)
+	bar.tty = true
+
+	bar.Increment("a.html")
+	if want := "\x1b[2K\rGenerating pages [#######-----------------------] 1/4 a.html"; sb.String() != want {
+		t.Errorf("Expected %q, got %q", want, sb.String())
+	}
+
+	sb.Reset()
+	bar.Finish()
+	if sb.String() != "\x1b[2K\r" {
+		t.Errorf("Expected cleared line, got %q", sb.String())
+	}
+}
+
+func TestBarConcurrent(t *testing.T) {
+	var sb strings.Builder
+	bar := New(&sb, "Generating pages", 100)
+
+	var wg sync.WaitGroup
+	for range 100 {
+		wg.Go(func() { bar.Increment("") })
+	}
+	wg.Wait()
+
+	if bar.done != 100 {
+		t.Errorf("Expected 100 completed steps, got %d", bar.done)
+	}
+	if !strings.HasSuffix(sb.String(), "100/100 (100%)\n") {
+		t.Errorf("Expected final progress line, got %q", sb.String())
+	}
+}
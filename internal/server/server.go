@@ -0,0 +1,165 @@
+/*
+Copyright (c) Tobias Schäfer. All rights reserved.
+Licensed under the MIT license, see LICENSE in the project root for details.
+*/
+package server
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"path"
+	"strings"
+	"sync"
+)
+
+// ReloadPath is the path of the server-sent events stream announcing
+// report regenerations
+const ReloadPath = "/_reload"
+
+// reloadScript is injected into every served HTML page and reloads the page
+// on a reload event
+const reloadScript = `<script>
+(() => {
+  const source = new EventSource('` + ReloadPath + `');
+  source.addEventListener('reload', () => window.location.reload());
+})();
+</script>
+`
+
+// Server serves a report directory and pushes reloads to open pages
+type Server struct {
+	root  http.FileSystem
+	files http.Handler
+
+	mu      sync.Mutex
+	clients map[chan struct{}]struct{}
+}
+
+// New creates a server for the report directory
+func New(dir string) *Server {
+	root := http.Dir(dir)
+
+	return &Server{
+		root:    root,
+		files:   http.FileServer(root),
+		clients: make(map[chan struct{}]struct{}),
+	}
+}
+
+// Reload notifies all connected pages to reload
+func (s *Server) Reload() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for client := range s.clients {
+		select {
+		case client <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// ServeHTTP serves the reload stream, HTML pages with the injected reload
+// script and all other files as is. Nothing is cached by the browser as
+// the report changes on regeneration.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Cache-Control", "no-cache")
+
+	name := path.Clean("/" + r.URL.Path)
+	if strings.HasSuffix(r.URL.Path, "/") {
+		name = path.Join(name, "index.html")
+	}
+
+	switch {
+	case name == ReloadPath:
+		s.serveEvents(w, r)
+	case strings.HasSuffix(name, ".html"):
+		s.servePage(w, r, name)
+	default:
+		s.files.ServeHTTP(w, r)
+	}
+}
+
+// servePage serves a HTML page with the reload script injected before the
+// closing body tag
+func (s *Server) servePage(w http.ResponseWriter, r *http.Request, name string) {
+	content, err := s.readFile(name)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			http.NotFound(w, r)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if idx := bytes.LastIndex(content, []byte("</body>")); idx >= 0 {
+		content = append(content[:idx:idx], append([]byte(reloadScript), content[idx:]...)...)
+	} else {
+		content = append(content, reloadScript...)
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_, _ = w.Write(content)
+}
+
+// readFile reads a regular file from the report directory
+func (s *Server) readFile(name string) ([]byte, error) {
+	f, err := s.root.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	if info.IsDir() {
+		return nil, fmt.Errorf("%s: %w", name, fs.ErrNotExist)
+	}
+
+	return io.ReadAll(f)
+}
+
+// serveEvents streams a reload event on every regeneration until the page
+// disconnects
+func (s *Server) serveEvents(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming not supported", http.StatusInternalServerError)
+		return
+	}
+
+	client := make(chan struct{}, 1)
+	s.mu.Lock()
+	s.clients[client] = struct{}{}
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		delete(s.clients, client)
+		s.mu.Unlock()
+	}()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	_, _ = fmt.Fprint(w, ": connected\n\n")
+	flusher.Flush()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-client:
+			_, _ = fmt.Fprint(w, "event: reload\ndata: \n\n")
+			flusher.Flush()
+		}
+	}
+}
@@ -0,0 +1,130 @@
+/*
+Copyright (c) Tobias Schäfer. All rights reserved.
+Licensed under the MIT license, see LICENSE in the project root for details.
+*/
+package server
+
+import (
+	"bufio"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func setupReport(t *testing.T) string {
+	dir := t.TempDir()
+	files := map[string]string{
+		"index.html":       "<html><body><h1>index</h1></body></html>",
+		"index.css":        "body {}",
+		"tree/main.html":   "<html><body>main</body></html>",
+		"tree/nobody.html": "<p>fragment</p>",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatalf("Failed to write file: %v", err)
+		}
+	}
+
+	return dir
+}
+
+func get(t *testing.T, handler http.Handler, path string) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+
+	return rec
+}
+
+func TestServePage(t *testing.T) {
+	s := New(setupReport(t))
+
+	for _, path := range []string{"/", "/index.html", "/tree/main.html"} {
+		rec := get(t, s, path)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Expected status 200 for %s, got %d", path, rec.Code)
+		}
+
+		body := rec.Body.String()
+		if !strings.Contains(body, reloadScript+"</body>") {
+			t.Errorf("Expected reload script before closing body tag for %s, got %q", path, body)
+		}
+		if ct := rec.Header().Get("Content-Type"); ct != "text/html; charset=utf-8" {
+			t.Errorf("Expected html content type for %s, got %q", path, ct)
+		}
+	}
+
+	rec := get(t, s, "/tree/nobody.html")
+	if !strings.HasSuffix(rec.Body.String(), reloadScript) {
+		t.Errorf("Expected reload script appended, got %q", rec.Body.String())
+	}
+}
+
+func TestServeFiles(t *testing.T) {
+	s := New(setupReport(t))
+
+	rec := get(t, s, "/index.css")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", rec.Code)
+	}
+	if rec.Body.String() != "body {}" {
+		t.Errorf("Expected unmodified asset, got %q", rec.Body.String())
+	}
+	if rec.Header().Get("Cache-Control") != "no-cache" {
+		t.Errorf("Expected no-cache header, got %q", rec.Header().Get("Cache-Control"))
+	}
+
+	for _, path := range []string{"/missing.html", "/tree/"} {
+		if rec := get(t, s, path); rec.Code != http.StatusNotFound {
+			t.Errorf("Expected status 404 for %s, got %d", path, rec.Code)
+		}
+	}
+}
+
+func TestReload(t *testing.T) {
+	s := New(setupReport(t))
+	ts := httptest.NewServer(s)
+	defer ts.Close()
+
+	resp, err := http.Get(ts.URL + ReloadPath)
+	if err != nil {
+		t.Fatalf("Failed to connect to reload stream: %v", err)
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("Expected event stream content type, got %q", ct)
+	}
+
+	reader := bufio.NewReader(resp.Body)
+	if line, err := reader.ReadString('\n'); err != nil || line != ": connected\n" {
+		t.Fatalf("Expected connected comment, got %q (%v)", line, err)
+	}
+	_, _ = reader.ReadString('\n')
+
+	s.Reload()
+
+	done := make(chan string, 1)
+	go func() {
+		line, _ := reader.ReadString('\n')
+		done <- line
+	}()
+
+	select {
+	case line := <-done:
+		if line != "event: reload\n" {
+			t.Errorf("Expected reload event, got %q", line)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected reload event, got none")
+	}
+}
@@ -0,0 +1,132 @@
+/*
+Copyright (c) Tobias Schäfer. All rights reserved.
+Licensed under the MIT license, see LICENSE in the project root for details.
+*/
+package source
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Rewrite replaces a path prefix of profile file names, e.g. the checkout
+// path of a CI runner by the local path
+type Rewrite struct {
+	From string
+	To   string
+}
+
+// ParseRewrite parses a rewrite rule in the form from=to
+func ParseRewrite(rule string) (Rewrite, error) {
+	from, to, ok := strings.Cut(rule, "=")
+	from = strings.TrimSuffix(strings.TrimSpace(from), "/")
+	if !ok || from == "" {
+		return Rewrite{}, fmt.Errorf("invalid rewrite rule %q, must be from=to", rule)
+	}
+
+	return Rewrite{From: from, To: strings.TrimSpace(to)}, nil
+}
+
+// Apply rewrites the name if it starts with the prefix of the rule
+func (r Rewrite) Apply(name string) (string, bool) {
+	if name != r.From && !strings.HasPrefix(name, r.From+"/") {
+		return name, false
+	}
+
+	rest := strings.TrimPrefix(strings.TrimPrefix(name, r.From), "/")
+	if r.To == "" {
+		return rest, true
+	}
+
+	return path.Join(r.To, rest), true
+}
+
+// Missing describes a source file that could not be found
+type Missing struct {
+	Name string
+	Path string
+}
+
+// Resolver locates source files on disk below a source root and keeps
+// track of the files that could not be found
+type Resolver struct {
+	root     string
+	rewrites []Rewrite
+
+	mu      sync.Mutex
+	missing map[string]string
+}
+
+// New creates a resolver for the source root with optional rewrite rules,
+// the first matching rule applies
+func New(root string, rewrites ...Rewrite) *Resolver {
+	return &Resolver{
+		root:     root,
+		rewrites: rewrites,
+		missing:  make(map[string]string),
+	}
+}
+
+// Rewrite applies the first matching rewrite rule to a profile file name
+// and reports whether any rule matched
+func (r *Resolver) Rewrite(name string) (string, bool) {
+	for _, rule := range r.rewrites {
+		if rewritten, ok := rule.Apply(name); ok {
+			return rewritten, true
+		}
+	}
+
+	return name, false
+}
+
+// Path returns the location on disk of a path relative to the source root,
+// absolute paths are returned as is
+func (r *Resolver) Path(localPath string) string {
+	localPath = filepath.FromSlash(localPath)
+	if filepath.IsAbs(localPath) {
+		return localPath
+	}
+
+	return filepath.Join(r.root, localPath)
+}
+
+// ReadFile reads a source file relative to the source root, files that do
+// not exist are recorded as missing
+func (r *Resolver) ReadFile(localPath string) ([]byte, error) {
+	diskPath := r.Path(localPath)
+
+	content, err := os.ReadFile(diskPath)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			r.mu.Lock()
+			r.missing[localPath] = diskPath
+			r.mu.Unlock()
+		}
+		return nil, fmt.Errorf("failed to read source file %s: %w", localPath, err)
+	}
+
+	return content, nil
+}
+
+// Missing returns the source files that could not be found, sorted by name
+func (r *Resolver) Missing() []Missing {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	missing := make([]Missing, 0, len(r.missing))
+	for name, diskPath := range r.missing {
+		missing = append(missing, Missing{Name: name, Path: diskPath})
+	}
+	sort.Slice(missing, func(i, j int) bool {
+		return missing[i].Name < missing[j].Name
+	})
+
+	return missing
+}
@@ -0,0 +1,93 @@
+/*
+Copyright (c) Tobias Schäfer. All rights reserved.
+Licensed under the MIT license, see LICENSE in the project root for details.
+*/
+package source
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestParseRewrite(t *testing.T) {
+	rule, err := ParseRewrite("/home/runner/work/repo/ = src")
+	if err != nil {
+		t.Fatalf("ParseRewrite failed: %v", err)
+	}
+	if rule != (Rewrite{From: "/home/runner/work/repo", To: "src"}) {
+		t.Errorf("Unexpected rewrite rule %+v", rule)
+	}
+
+	for _, invalid := range []string{"", "no-separator", "=to"} {
+		if _, err := ParseRewrite(invalid); err == nil {
+			t.Errorf("Expected error for rule %q, got nil", invalid)
+		}
+	}
+}
+
+func TestRewrite(t *testing.T) {
+	r := New(".",
+		Rewrite{From: "/home/runner/work/repo", To: ""},
+		Rewrite{From: "github.com/fork/repo", To: "github.com/origin/repo"},
+	)
+
+	tests := []struct {
+		name    string
+		want    string
+		matched bool
+	}{
+		{"/home/runner/work/repo/pkg/a.go", "pkg/a.go", true},
+		{"/home/runner/work/repository/a.go", "/home/runner/work/repository/a.go", false},
+		{"github.com/fork/repo/main.go", "github.com/origin/repo/main.go", true},
+		{"github.com/origin/repo/main.go", "github.com/origin/repo/main.go", false},
+	}
+
+	for _, tt := range tests {
+		got, matched := r.Rewrite(tt.name)
+		if got != tt.want || matched != tt.matched {
+			t.Errorf("Expected %s to rewrite to %s (%v), got %s (%v)", tt.name, tt.want, tt.matched, got, matched)
+		}
+	}
+}
+
+func TestReadFile(t *testing.T) {
+	root := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(root, "pkg"), 0o755); err != nil {
+		t.Fatalf("Failed to create directory: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "pkg", "a.go"), []byte("package pkg"), 0o644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+
+	r := New(root)
+
+	content, err := r.ReadFile("pkg/a.go")
+	if err != nil {
+		t.Fatalf("ReadFile failed: %v", err)
+	}
+	if string(content) != "package pkg" {
+		t.Errorf("Unexpected content %q", content)
+	}
+
+	if _, err := r.ReadFile(filepath.Join(root, "pkg", "a.go")); err != nil {
+		t.Errorf("Expected absolute path to be read as is, got %v", err)
+	}
+
+	_, err = r.ReadFile("pkg/b.go")
+	if !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("Expected not exist error, got %v", err)
+	}
+	_, _ = r.ReadFile("main.go")
+
+	want := []Missing{
+		{Name: "main.go", Path: filepath.Join(root, "main.go")},
+		{Name: "pkg/b.go", Path: filepath.Join(root, "pkg", "b.go")},
+	}
+	if got := r.Missing(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected missing files %v, got %v", want, got)
+	}
+}
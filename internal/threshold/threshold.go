@@ -0,0 +1,101 @@
+/*
+Copyright (c) Tobias Schäfer. All rights reserved.
+Licensed under the MIT license, see LICENSE in the project root for details.
+*/
+package threshold
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/tschaefer/cover-ui/internal/coverage"
+)
+
+// Thresholds holds the minimum coverage percentages, a value of zero
+// disables the respective check
+type Thresholds struct {
+	Total   float64
+	Package float64
+	File    float64
+	Patch   float64
+}
+
+// Validate checks that all thresholds are within 0 and 100 percent
+func (t Thresholds) Validate() error {
+	for name, v := range map[string]float64{"total": t.Total, "package": t.Package, "file": t.File, "patch": t.Patch} {
+		if math.IsNaN(v) || v < 0 || v > 100 {
+			return fmt.Errorf("invalid %s threshold %v, must be within 0 and 100", name, v)
+		}
+	}
+
+	return nil
+}
+
+// Violation describes a coverage below its threshold
+type Violation struct {
+	Scope       string
+	Name        string
+	CoveragePct float64
+	Threshold   float64
+}
+
+// String returns a human readable description of the violation
+func (v Violation) String() string {
+	if v.Name == "" {
+		return fmt.Sprintf("%s coverage %.2f%% is below %.2f%%", v.Scope, v.CoveragePct, v.Threshold)
+	}
+
+	return fmt.Sprintf("%s %s coverage %.2f%% is below %.2f%%", v.Scope, v.Name, v.CoveragePct, v.Threshold)
+}
+
+// Check evaluates the thresholds against the file metrics and the optional
+// patch metrics. Packages are the directories holding the files, files and
+// packages without statements are not checked.
+func Check(files []*coverage.FileMetrics, patch *coverage.PatchMetrics, t Thresholds) []Violation {
+	var violations []Violation
+
+	if t.Total > 0 {
+		total := coverage.Statistics(files)
+		if total.CoveragePct < t.Total {
+			violations = append(violations, Violation{"total", "", total.CoveragePct, t.Total})
+		}
+	}
+
+	if t.Package > 0 {
+		for _, pkg := range packages(files) {
+			if pkg.TotalStmts > 0 && pkg.CoveragePct < t.Package {
+				violations = append(violations, Violation{"package", pkg.name, pkg.CoveragePct, t.Package})
+			}
+		}
+	}
+
+	if t.File > 0 {
+		for _, f := range files {
+			if f.TotalStmts > 0 && f.CoveragePct < t.File {
+				violations = append(violations, Violation{"file", f.LocalPath, f.CoveragePct, t.File})
+			}
+		}
+	}
+
+	if t.Patch > 0 && patch != nil && patch.TrackedLines > 0 && patch.CoveragePct < t.Patch {
+		violations = append(violations, Violation{"patch", "", patch.CoveragePct, t.Patch})
+	}
+
+	return violations
+}
+
+// packageMetrics holds the aggregated statement coverage of a directory
+type packageMetrics struct {
+	name string
+	coverage.TotalMetrics
+}
+
+// packages groups the files by directory, sorted by directory name
+func packages(files []*coverage.FileMetrics) []packageMetrics {
+	var pkgs []packageMetrics
+	for _, pkg := range coverage.Packages(files) {
+		pkgs = append(pkgs, packageMetrics{pkg.Dir, *coverage.Statistics(pkg.Files)})
+	}
+
+	return pkgs
+}
@@ -0,0 +1,71 @@
+/*
+Copyright (c) Tobias Schäfer. All rights reserved.
+Licensed under the MIT license, see LICENSE in the project root for details.
+*/
+package threshold
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/tschaefer/cover-ui/internal/coverage"
+)
+
+func testFiles() []*coverage.FileMetrics {
+	return []*coverage.FileMetrics{
+		{LocalPath: "main.go", TotalStmts: 10, CoveredStmts: 9, CoveragePct: 90},
+		{LocalPath: "pkg/a.go", TotalStmts: 10, CoveredStmts: 8, CoveragePct: 80},
+		{LocalPath: "pkg/b.go", TotalStmts: 10, CoveredStmts: 2, CoveragePct: 20},
+		{LocalPath: "pkg/empty.go"},
+	}
+}
+
+func TestCheck(t *testing.T) {
+	patch := &coverage.PatchMetrics{TrackedLines: 4, CoveredLines: 2, CoveragePct: 50}
+	thresholds := Thresholds{Total: 70, Package: 60, File: 50, Patch: 75}
+
+	violations := Check(testFiles(), patch, thresholds)
+
+	want := []Violation{
+		{"total", "", 63.33, 70},
+		{"package", "pkg", 50, 60},
+		{"file", "pkg/b.go", 20, 50},
+		{"patch", "", 50, 75},
+	}
+	if !reflect.DeepEqual(violations, want) {
+		t.Errorf("Expected violations %v, got %v", want, violations)
+	}
+}
+
+func TestCheckDisabled(t *testing.T) {
+	violations := Check(testFiles(), nil, Thresholds{Patch: 90})
+	if len(violations) != 0 {
+		t.Errorf("Expected no violations, got %v", violations)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	if err := (Thresholds{Total: 80, File: 100}).Validate(); err != nil {
+		t.Errorf("Expected valid thresholds, got %v", err)
+	}
+
+	if err := (Thresholds{Package: 101}).Validate(); err == nil {
+		t.Error("Expected error for threshold above 100, got nil")
+	}
+
+	if err := (Thresholds{File: -1}).Validate(); err == nil {
+		t.Error("Expected error for negative threshold, got nil")
+	}
+}
+
+func TestViolationString(t *testing.T) {
+	v := Violation{"file", "pkg/b.go", 20, 50}
+	if got := v.String(); got != "file pkg/b.go coverage 20.00% is below 50.00%" {
+		t.Errorf("Unexpected violation string: %s", got)
+	}
+
+	v = Violation{"total", "", 63.33, 70}
+	if got := v.String(); got != "total coverage 63.33% is below 70.00%" {
+		t.Errorf("Unexpected violation string: %s", got)
+	}
+}
@@ -0,0 +1,233 @@
+/*
+Copyright (c) Tobias Schäfer. All rights reserved.
+Licensed under the MIT license, see LICENSE in the project root for details.
+*/
+package untested
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io"
+	"os/exec"
+	"path/filepath"
+	"sort"
+
+	"golang.org/x/tools/cover"
+
+	"github.com/tschaefer/cover-ui/internal/module"
+)
+
+// listedPackage holds the fields of go list output in use
+type listedPackage struct {
+	ImportPath string
+	Dir        string
+	GoFiles    []string
+	CgoFiles   []string
+}
+
+// Profiles returns profiles with uncovered blocks for all non-test Go files
+// of the modules below root that are not among the tested files, given by
+// their paths on disk. Files are listed by go list, so build constraints
+// apply; files without statements are left out.
+func Profiles(root string, modules module.Modules, tested []string, mode string) ([]*cover.Profile, error) {
+	known := make(map[string]bool, len(tested))
+	for _, path := range tested {
+		known[canonical(path)] = true
+	}
+
+	var profiles []*cover.Profile
+	for _, mod := range modules {
+		packages, err := list(filepath.Join(root, mod.Dir))
+		if err != nil {
+			return nil, fmt.Errorf("failed to list packages of %s: %w", mod.Path, err)
+		}
+
+		for _, pkg := range packages {
+			for _, name := range append(pkg.GoFiles, pkg.CgoFiles...) {
+				path := filepath.Join(pkg.Dir, name)
+				if known[canonical(path)] {
+					continue
+				}
+
+				blocks, err := parseBlocks(path)
+				if err != nil {
+					return nil, err
+				}
+				if len(blocks) == 0 {
+					continue
+				}
+
+				profiles = append(profiles, &cover.Profile{FileName: pkg.ImportPath + "/" + name, Mode: mode, Blocks: blocks})
+			}
+		}
+	}
+
+	sort.Slice(profiles, func(i, j int) bool {
+		return profiles[i].FileName < profiles[j].FileName
+	})
+
+	return profiles, nil
+}
+
+// canonical returns the absolute path of a file with symbolic links
+// resolved, so paths given relative to the source root match the
+// directories reported by go list
+func canonical(path string) string {
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		path = resolved
+	}
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+
+	return path
+}
+
+// list runs go list for all packages of the module in dir
+func list(dir string) ([]listedPackage, error) {
+	cmd := exec.Command("go", "list", "-e", "-json=ImportPath,Dir,GoFiles,CgoFiles", "./...")
+	cmd.Dir = dir
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("go list failed: %w: %s", err, bytes.TrimSpace(stderr.Bytes()))
+	}
+
+	var packages []listedPackage
+	dec := json.NewDecoder(bytes.NewReader(out))
+	for {
+		var pkg listedPackage
+		if err := dec.Decode(&pkg); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return nil, fmt.Errorf("failed to decode go list output: %w", err)
+		}
+		packages = append(packages, pkg)
+	}
+
+	return packages, nil
+}
+
+// parseBlocks parses a Go file and returns a block for every statement
+func parseBlocks(path string) ([]cover.ProfileBlock, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, path, nil, parser.SkipObjectResolution)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse source file %s: %w", path, err)
+	}
+
+	return blocks(fset, file), nil
+}
+
+// blocks returns an uncovered block for every statement of the function
+// bodies, counting statements like the cover tool does: every statement of
+// a statement list counts once, compound statements with the range of
+// their header up to the opening brace of the body.
+func blocks(fset *token.FileSet, file *ast.File) []cover.ProfileBlock {
+	var result []cover.ProfileBlock
+
+	add := func(from, to token.Pos) {
+		start := fset.Position(from)
+		end := fset.Position(to)
+		result = append(result, cover.ProfileBlock{
+			StartLine: start.Line,
+			StartCol:  start.Column,
+			EndLine:   end.Line,
+			EndCol:    end.Column,
+			NumStmt:   1,
+		})
+	}
+
+	var visitList func(list []ast.Stmt)
+	var visitStmt func(stmt ast.Stmt)
+
+	visitStmt = func(stmt ast.Stmt) {
+		switch s := stmt.(type) {
+		case *ast.BlockStmt:
+			visitList(s.List)
+		case *ast.LabeledStmt:
+			visitStmt(s.Stmt)
+		case *ast.IfStmt:
+			add(s.Pos(), s.Body.Lbrace)
+			visitList(s.Body.List)
+			if s.Else != nil {
+				visitStmt(s.Else)
+			}
+		case *ast.ForStmt:
+			add(s.Pos(), s.Body.Lbrace)
+			visitList(s.Body.List)
+		case *ast.RangeStmt:
+			add(s.Pos(), s.Body.Lbrace)
+			visitList(s.Body.List)
+		case *ast.SwitchStmt:
+			add(s.Pos(), s.Body.Lbrace)
+			visitClauses(s.Body, visitList)
+		case *ast.TypeSwitchStmt:
+			add(s.Pos(), s.Body.Lbrace)
+			visitClauses(s.Body, visitList)
+		case *ast.SelectStmt:
+			add(s.Pos(), s.Body.Lbrace)
+			visitClauses(s.Body, visitList)
+		default:
+			add(s.Pos(), s.End())
+			visitFuncLits(s, visitList)
+		}
+	}
+
+	visitList = func(list []ast.Stmt) {
+		for _, stmt := range list {
+			visitStmt(stmt)
+		}
+	}
+
+	for _, decl := range file.Decls {
+		fn, ok := decl.(*ast.FuncDecl)
+		if !ok {
+			visitFuncLits(decl, visitList)
+			continue
+		}
+		if fn.Body != nil {
+			visitList(fn.Body.List)
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].StartLine < result[j].StartLine ||
+			result[i].StartLine == result[j].StartLine && result[i].StartCol < result[j].StartCol
+	})
+
+	return result
+}
+
+// visitClauses visits the bodies of the case and comm clauses of a switch
+// or select statement
+func visitClauses(body *ast.BlockStmt, visitList func([]ast.Stmt)) {
+	for _, stmt := range body.List {
+		switch clause := stmt.(type) {
+		case *ast.CaseClause:
+			visitList(clause.Body)
+		case *ast.CommClause:
+			visitList(clause.Body)
+		}
+	}
+}
+
+// visitFuncLits visits the bodies of function literals within a simple
+// statement or declaration
+func visitFuncLits(node ast.Node, visitList func([]ast.Stmt)) {
+	ast.Inspect(node, func(n ast.Node) bool {
+		if lit, ok := n.(*ast.FuncLit); ok {
+			visitList(lit.Body.List)
+			return false
+		}
+		return true
+	})
+}
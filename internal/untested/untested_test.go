@@ -0,0 +1,98 @@
+/*
+Copyright (c) Tobias Schäfer. All rights reserved.
+Licensed under the MIT license, see LICENSE in the project root for details.
+*/
+package untested
+
+import (
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/tschaefer/cover-ui/internal/module"
+)
+
+func writeFile(t *testing.T, path, content string) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatalf("Failed to create directory: %v", err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+}
+
+func TestBlocks(t *testing.T) {
+	source := `package calc
+
+var double = func(x int) int { return 2 * x }
+
+func Sum(values []int) int {
+	total := 0
+	for _, v := range values {
+		if v < 0 {
+			continue
+		} else if v > 100 {
+			v = 100
+		}
+		total += v
+	}
+	switch {
+	case total > 10:
+		total = double(total)
+	default:
+	}
+	return total
+}
+
+func Declared()
+`
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "calc.go", source, 0)
+	if err != nil {
+		t.Fatalf("Failed to parse source: %v", err)
+	}
+
+	got := blocks(fset, file)
+	if len(got) != 11 {
+		t.Fatalf("Expected 11 blocks, got %d: %v", len(got), got)
+	}
+
+	first := got[0]
+	if first.StartLine != 3 || first.NumStmt != 1 || first.Count != 0 {
+		t.Errorf("Expected uncovered block of func literal at line 3, got %+v", first)
+	}
+
+	loop := got[2]
+	if loop.StartLine != 7 || loop.EndLine != 7 || loop.EndCol != 27 {
+		t.Errorf("Expected range header block at 7.2,7.27, got %+v", loop)
+	}
+}
+
+func TestProfiles(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "go.mod"), "module example.com/calc\n\ngo 1.21\n")
+	writeFile(t, filepath.Join(root, "calc", "calc.go"), "package calc\n\nfunc Abs(x int) int {\n\tif x < 0 {\n\t\treturn -x\n\t}\n\treturn x\n}\n")
+	writeFile(t, filepath.Join(root, "calc", "calc_test.go"), "package calc\n\nimport \"testing\"\n\nfunc TestAbs(t *testing.T) {}\n")
+	writeFile(t, filepath.Join(root, "calc", "types.go"), "package calc\n\ntype Number int\n")
+	writeFile(t, filepath.Join(root, "tested", "tested.go"), "package tested\n\nfunc F() {\n\tprintln()\n}\n")
+
+	tested := []string{filepath.Join(root, "tested", "tested.go")}
+	profiles, err := Profiles(root, module.Modules{{Path: "example.com/calc", Dir: "."}}, tested, "set")
+	if err != nil {
+		t.Fatalf("Profiles failed: %v", err)
+	}
+
+	if len(profiles) != 1 {
+		t.Fatalf("Expected 1 profile, got %d", len(profiles))
+	}
+
+	p := profiles[0]
+	if p.FileName != "example.com/calc/calc/calc.go" || p.Mode != "set" {
+		t.Errorf("Unexpected profile %s (%s)", p.FileName, p.Mode)
+	}
+	if len(p.Blocks) != 3 {
+		t.Errorf("Expected 3 blocks, got %d", len(p.Blocks))
+	}
+}
@@ -0,0 +1,158 @@
+/*
+Copyright (c) Tobias Schäfer. All rights reserved.
+Licensed under the MIT license, see LICENSE in the project root for details.
+*/
+package watch
+
+import (
+	"context"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Stamp holds the modification state of a file
+type Stamp struct {
+	ModTime time.Time
+	Size    int64
+}
+
+// Snapshot maps file paths to their modification state
+type Snapshot map[string]Stamp
+
+// tree is a directory watched recursively for files with a suffix
+type tree struct {
+	dir    string
+	suffix string
+}
+
+// Watcher polls files and directory trees for changes. Polling keeps the
+// watcher portable and free of dependencies, the granularity of the
+// interval is plenty for regenerating a report.
+type Watcher struct {
+	interval time.Duration
+	globs    []string
+	trees    []tree
+}
+
+// New creates a watcher polling with the given interval
+func New(interval time.Duration) *Watcher {
+	return &Watcher{interval: interval}
+}
+
+// AddGlob watches the files matching the pattern, the pattern is evaluated
+// on every poll to pick up new files
+func (w *Watcher) AddGlob(pattern string) {
+	w.globs = append(w.globs, pattern)
+}
+
+// AddTree watches the files with the suffix below the directory, an empty
+// suffix matches all files. Hidden directories are skipped.
+func (w *Watcher) AddTree(dir, suffix string) {
+	w.trees = append(w.trees, tree{dir, suffix})
+}
+
+// Scan returns the current modification state of all watched files,
+// unreadable files and directories are left out
+func (w *Watcher) Scan() Snapshot {
+	snapshot := make(Snapshot)
+
+	for _, pattern := range w.globs {
+		matches, _ := filepath.Glob(pattern)
+		for _, match := range matches {
+			if info, err := os.Stat(match); err == nil && !info.IsDir() {
+				snapshot[match] = Stamp{info.ModTime(), info.Size()}
+			}
+		}
+	}
+
+	for _, t := range w.trees {
+		_ = filepath.WalkDir(t.dir, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return nil
+			}
+			if d.IsDir() {
+				if path != t.dir && strings.HasPrefix(d.Name(), ".") {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if !strings.HasSuffix(path, t.suffix) {
+				return nil
+			}
+			if info, err := d.Info(); err == nil {
+				snapshot[path] = Stamp{info.ModTime(), info.Size()}
+			}
+			return nil
+		})
+	}
+
+	return snapshot
+}
+
+// Changed returns the sorted paths of files added, modified or removed
+// between two snapshots
+func Changed(prev, next Snapshot) []string {
+	var changed []string
+	for path, stamp := range next {
+		if old, ok := prev[path]; !ok || old != stamp {
+			changed = append(changed, path)
+		}
+	}
+	for path := range prev {
+		if _, ok := next[path]; !ok {
+			changed = append(changed, path)
+		}
+	}
+	sort.Strings(changed)
+
+	return changed
+}
+
+// Run polls until the context is done and calls onChange with the changed
+// paths. Changes are only reported once a poll sees no further changes, so
+// files still being written are not picked up halfway.
+func (w *Watcher) Run(ctx context.Context, onChange func(changed []string)) {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	last := w.Scan()
+	var pending []string
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		next := w.Scan()
+		changed := Changed(last, next)
+		last = next
+
+		if len(changed) > 0 {
+			pending = append(pending, changed...)
+			continue
+		}
+		if len(pending) > 0 {
+			onChange(unique(pending))
+			pending = nil
+		}
+	}
+}
+
+// unique returns the sorted paths without duplicates
+func unique(paths []string) []string {
+	sort.Strings(paths)
+
+	var result []string
+	for i, path := range paths {
+		if i == 0 || path != paths[i-1] {
+			result = append(result, path)
+		}
+	}
+
+	return result
+}
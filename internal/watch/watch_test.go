@@ -0,0 +1,99 @@
+/*
+Copyright (c) Tobias Schäfer. All rights reserved.
+Licensed under the MIT license, see LICENSE in the project root for details.
+*/
+package watch
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func writeFile(t *testing.T, path, content string) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatalf("Failed to create directory: %v", err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+}
+
+func TestScan(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "main.go"), "package main")
+	writeFile(t, filepath.Join(dir, "README.md"), "readme")
+	writeFile(t, filepath.Join(dir, "pkg", "pkg.go"), "package pkg")
+	writeFile(t, filepath.Join(dir, ".git", "hook.go"), "package hook")
+	writeFile(t, filepath.Join(dir, "coverage.out"), "mode: set")
+
+	w := New(time.Second)
+	w.AddTree(dir, ".go")
+	w.AddGlob(filepath.Join(dir, "*.out"))
+
+	var got []string
+	for path := range w.Scan() {
+		rel, _ := filepath.Rel(dir, path)
+		got = append(got, filepath.ToSlash(rel))
+	}
+
+	want := []string{"coverage.out", "main.go", "pkg/pkg.go"}
+	if !reflect.DeepEqual(unique(got), want) {
+		t.Errorf("Expected watched files %v, got %v", want, unique(got))
+	}
+}
+
+func TestChanged(t *testing.T) {
+	now := time.Now()
+	prev := Snapshot{
+		"a.go": {now, 1},
+		"b.go": {now, 1},
+		"c.go": {now, 1},
+	}
+	next := Snapshot{
+		"a.go": {now, 1},
+		"b.go": {now.Add(time.Second), 1},
+		"d.go": {now, 1},
+	}
+
+	want := []string{"b.go", "c.go", "d.go"}
+	if got := Changed(prev, next); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected changed files %v, got %v", want, got)
+	}
+
+	if got := Changed(prev, prev); len(got) != 0 {
+		t.Errorf("Expected no changes, got %v", got)
+	}
+}
+
+func TestRun(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "main.go")
+	writeFile(t, path, "package main")
+
+	w := New(10 * time.Millisecond)
+	w.AddTree(dir, ".go")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	changes := make(chan []string, 1)
+	go w.Run(ctx, func(changed []string) {
+		changes <- changed
+	})
+
+	time.Sleep(50 * time.Millisecond)
+	writeFile(t, path, "package main\n\nfunc main() {}")
+
+	select {
+	case changed := <-changes:
+		if !reflect.DeepEqual(changed, []string{path}) {
+			t.Errorf("Expected change of %s, got %v", path, changed)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected change notification, got none")
+	}
+}